@@ -8,75 +8,81 @@ package main
 
 import (
 	"aviasales/internal/application"
+	"aviasales/internal/config"
 	"aviasales/internal/services"
-	"aviasales/internal/services/storage"
 	"aviasales/pkg/logger"
 	"aviasales/pkg/logger/zaplogger"
 	"context"
-	"io/ioutil"
+	"flag"
+	"fmt"
 	"os"
 	"os/signal"
-	"path/filepath"
 	"syscall"
 )
 
-const (
-	fixturesDirectory = "./fixtures"
-	parsersLimit      = 5
-)
-
 func main() {
+	cfg, err := config.Load(os.Args[1:], os.LookupEnv)
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.PrintConfig {
+		if err = cfg.Print(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
 	zapl, err := zaplogger.NewProduction()
 	if err != nil {
 		logger.FatalE(ctx, "unable to create logger", err)
 	}
+	// logger is synced last, after everything is stopped
 	defer zapl.Sync()
 	logger.SetGlobalLogger(zapl)
 
-	var logLevel logger.Level
-	err = logLevel.Set("debug")
-	if err != nil {
-		logger.FatalE(ctx, "unable to parse loglevel", err)
-	}
-	logger.SetLevel(logLevel)
+	logger.SetLevel(cfg.GetLogLevel())
 
-	serviceFactory := services.NewServiceFactory(ctx)
-	parserWorkerPool := application.SpawnWorkers(parsersLimit)
+	serviceFactory := services.NewServiceFactory(ctx, cfg)
+	parserWorkerPool := serviceFactory.Parser()
 
-	err = loadData(ctx, serviceFactory.Storage(), parserWorkerPool)
-	if err != nil {
-		logger.FatalE(ctx, "unable to load xml files", err)
-	}
-
-	server := application.NewServer(ctx, serviceFactory)
+	// server is stopped before parsing is drained, so uploads in flight
+	// are still parsed
+	serverCtx, stopServer := context.WithCancel(ctx)
+	server := application.NewServer(serverCtx, serviceFactory)
 	go func() {
 		quit := make(chan os.Signal, 1)
 		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 		defer signal.Stop(quit)
 		<-quit
-		cancel()
-		parserWorkerPool.Flush()
+		stopServer()
 	}()
 
-	server.Run()
-}
-
-func loadData(ctx context.Context, storage storage.IStorage, pool *application.WorkerPool) error {
-	files, err := ioutil.ReadDir(fixturesDirectory)
-	if err != nil {
-		return err
+	// files are ingested in background, readiness is reported by progress
+	serviceFactory.Watcher().Start()
+	runErr := server.Run()
+	if runErr != nil {
+		// the server isn't started, files being parsed aren't waited for
+		cancel()
 	}
 
-	for _, file := range files {
-		fileName := filepath.Join(fixturesDirectory, file.Name())
-		pool.Put(&application.WorkerParserQueue{
-			Ctx:      ctx,
-			FileName: fileName,
-			Storage:  storage,
-		})
+	logger.Info(ctx, "Draining parser workers...")
+	serviceFactory.Watcher().Stop()
+	parserWorkerPool.Flush()
+	if err = serviceFactory.Storage().Close(); err != nil {
+		logger.Error(ctx, "unable to close storage", err)
 	}
-
-	return nil
+	cancel()
+	if runErr != nil {
+		logger.Error(ctx, "Application is failed to start", runErr)
+		zapl.Sync()
+		os.Exit(1)
+	}
+	logger.Info(ctx, "Application is stopped")
 }
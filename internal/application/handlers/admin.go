@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WatchedFilesHandler struct{}
+
+func (s *WatchedFilesHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	ctx.JSON(http.StatusOK, services.Watcher().GetFiles())
+}
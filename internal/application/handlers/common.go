@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"aviasales/internal/services"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -9,3 +10,29 @@ import (
 type IHandler interface {
 	Process(ctx *gin.Context, services services.IServiceFactory)
 }
+
+// splitCodes accepts both repeated and comma separated query values.
+func splitCodes(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, code := range strings.Split(value, ",") {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if code != "" {
+				result = append(result, code)
+			}
+		}
+	}
+	return result
+}
+
+// paginate returns bounds of a page of count items, limit 0 means all of them.
+func paginate(count, offset, limit int) (start, end int) {
+	if offset >= count {
+		return count, count
+	}
+	end = count
+	if limit > 0 && limit < count-offset {
+		end = offset + limit
+	}
+	return offset, end
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"aviasales/internal/services"
+	"aviasales/pkg/entities"
 	"encoding/json"
 	"net/http"
 
@@ -17,6 +18,8 @@ type CompareHandlerQuery struct {
 	Ticket1 string `json:"ticket1" form:"ticket1" binding:"required"`
 	// Required: true
 	Ticket2 string `json:"ticket2" form:"ticket2" binding:"required"`
+	// Currency code to convert prices to, e.g. USD; prices are in the currency of the partner when not set
+	Currency string `json:"currency" form:"currency" binding:"omitempty,len=3"`
 }
 
 func (s *CompareHandler) Process(
@@ -29,7 +32,14 @@ func (s *CompareHandler) Process(
 		return
 	}
 
-	diff, err := getCompare(services, query.Ticket1, query.Ticket2)
+	// tickets are compared as search responds with them
+	writer := newItineraryWriter(services, "", query.Currency, entities.Passengers{}.OrDefault())
+	if err := writer.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	diff, err := getCompare(services, writer, query.Ticket1, query.Ticket2)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 		return
@@ -38,12 +48,20 @@ func (s *CompareHandler) Process(
 	ctx.String(http.StatusOK, diff)
 }
 
-func getCompare(services services.IServiceFactory, t1, t2 string) (string, error) {
-	ticket1, err := services.Storage().GetByUUID(t1)
+func getCompare(services services.IServiceFactory, writer *itineraryWriter, t1, t2 string) (string, error) {
+	stored1, err := services.Storage().GetByUUID(t1)
+	if err != nil {
+		return "", err
+	}
+	stored2, err := services.Storage().GetByUUID(t2)
+	if err != nil {
+		return "", err
+	}
+	ticket1, err := writer.convert(stored1)
 	if err != nil {
 		return "", err
 	}
-	ticket2, err := services.Storage().GetByUUID(t2)
+	ticket2, err := writer.convert(stored2)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CompareResponsesHandler struct{}
+
+// swagger:parameters CompareResponsesHandlerQuery
+type CompareResponsesHandlerQuery struct {
+	// Required: true
+	Response1 string `json:"response1" form:"response1" binding:"required"`
+	// Required: true
+	Response2 string `json:"response2" form:"response2" binding:"required"`
+	// Currency code to convert prices to, e.g. USD
+	Currency string `json:"currency" form:"currency" binding:"omitempty,len=3"`
+}
+
+func (s *CompareResponsesHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query CompareResponsesHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	diff, err := services.Compare().CompareResponses(query.Response1, query.Response2, query.Currency)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, diff)
+}
@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"aviasales/pkg/entities"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareHandler_Currency(t *testing.T) {
+	factory := newServices(t)
+	defer factory.Parser().Flush()
+
+	var best []*entities.Itinerary
+	serve(t, &SearchHandler{}, factory, "source=DXB&destination=BKK&type=cheapest&limit=2", &best)
+	if !assert.Len(t, best, 2) {
+		return
+	}
+	query := "ticket1=" + string(best[0].UUID) + "&ticket2=" + string(best[1].UUID)
+
+	recorder := serve(t, &CompareHandler{}, factory, query+"&currency=USD", nil)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"Currency": "USD"`, "it should convert prices of both tickets")
+	assert.NotContains(t, recorder.Body.String(), `"Currency": "SGD"`)
+
+	recorder = serve(t, &CompareHandler{}, factory, query+"&currency=XXX", nil)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code, "it should reject unknown currency")
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/graph"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GraphSearchHandler struct{}
+
+// swagger:parameters GraphSearchHandlerQuery
+type GraphSearchHandlerQuery struct {
+	// Source airport, routes may start inside stored itineraries
+	// Required: true
+	Source string `json:"source" form:"source" binding:"required,len=3"`
+	// Destination airport, routes may end inside stored itineraries
+	// Required: true
+	Destination string `json:"destination" form:"destination" binding:"required,len=3"`
+	// Local departure date of the first segment, format: 2006-01-02
+	DepartureDate time.Time `json:"departureDate" form:"departureDate" time_format:"2006-01-02"`
+	// Min connection time, e.g. 2h; it can't be shorter than the airport rule, except for legs booked together
+	MinConnection time.Duration `json:"minConnection" form:"minConnection" binding:"omitempty,min=0"`
+	// Max connection time, e.g. 12h; it can't be longer than the configured one
+	MaxConnection time.Duration `json:"maxConnection" form:"maxConnection" binding:"omitempty,min=0"`
+	// Max number of segments; it can't be more than the configured one
+	MaxSegments int `json:"maxSegments" form:"maxSegments" binding:"omitempty,min=1"`
+	// Number of routes to skip
+	Offset int `json:"offset" form:"offset" binding:"omitempty,min=0"`
+	// Max number of routes, all when not set; total count is in X-Total-Count header
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=0"`
+}
+
+func (s *GraphSearchHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query GraphSearchHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	routes, err := services.Graph().FindRoutes(query.Source, query.Destination, graph.Options{
+		MinConnection: query.MinConnection,
+		MaxConnection: query.MaxConnection,
+		MaxSegments:   query.MaxSegments,
+		DepartureDate: query.DepartureDate,
+	})
+	if err == graph.ErrInvalidOptions {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	ctx.Header(totalCountHeader, strconv.Itoa(len(routes)))
+	start, end := paginate(len(routes), query.Offset, query.Limit)
+	ctx.JSON(http.StatusOK, routes[start:end])
+}
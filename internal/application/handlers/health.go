@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/watcher"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReadinessResponse struct {
+	Ready    bool
+	Progress watcher.Progress
+}
+
+// ReadinessHandler responds with 503 until the initial ingestion is finished
+// and storage has itineraries to search.
+type ReadinessHandler struct{}
+
+func (s *ReadinessHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	progress := services.Watcher().GetProgress()
+	result := ReadinessResponse{
+		Ready:    progress.Ready && isPopulated(services),
+		Progress: progress,
+	}
+
+	status := http.StatusOK
+	if !result.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, result)
+}
+
+type IngestionProgressHandler struct{}
+
+func (s *IngestionProgressHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	ctx.JSON(http.StatusOK, services.Watcher().GetProgress())
+}
+
+// isPopulated checks that any response, watched or uploaded, has itineraries.
+func isPopulated(services services.IServiceFactory) bool {
+	responses, err := services.Storage().GetResponses()
+	if err != nil {
+		return false
+	}
+	for _, response := range responses {
+		if response.ItinerariesCount > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// itineraryWriter responds with copies of stored itineraries priced for
+// passengers in the requested view and currency.
+type itineraryWriter struct {
+	view       string
+	toCurrency string
+	passengers entities.Passengers
+	currency   currency.IService
+}
+
+func newItineraryWriter(services services.IServiceFactory, view, toCurrency string, passengers entities.Passengers) *itineraryWriter {
+	return &itineraryWriter{
+		view:       view,
+		toCurrency: toCurrency,
+		passengers: passengers,
+		currency:   services.Currency(),
+	}
+}
+
+// validate checks the requested currency before itineraries are searched.
+func (w *itineraryWriter) validate() error {
+	if w.toCurrency == "" {
+		return nil
+	}
+	_, err := w.currency.GetRate(w.toCurrency, w.currency.GetBase())
+	return err
+}
+
+func (w *itineraryWriter) write(ctx *gin.Context, itinerary *entities.Itinerary) {
+	if itinerary == nil {
+		ctx.JSON(http.StatusOK, nil)
+		return
+	}
+
+	w.writeRanked(ctx, []*scoring.Ranked{{Itinerary: itinerary}}, true)
+}
+
+func (w *itineraryWriter) writeAll(ctx *gin.Context, itineraries []*entities.Itinerary) {
+	ranked := make([]*scoring.Ranked, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		ranked = append(ranked, &scoring.Ranked{Itinerary: itinerary})
+	}
+
+	w.writeRanked(ctx, ranked, false)
+}
+
+// writeRanked explains score of every itinerary in full view, single
+// responds with the only itinerary instead of a list.
+func (w *itineraryWriter) writeRanked(ctx *gin.Context, ranked []*scoring.Ranked, single bool) {
+	summaries := make([]*entities.ItinerarySummary, 0, len(ranked))
+	itineraries := make([]*entities.Itinerary, 0, len(ranked))
+	for _, item := range ranked {
+		itinerary, err := w.convert(item.Itinerary)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, err.Error())
+			return
+		}
+		itinerary.ScoreBreakdown = item.Breakdown
+		summaries = append(summaries, itinerary.GetSummary(w.passengers))
+		itineraries = append(itineraries, itinerary)
+	}
+
+	switch {
+	case w.view == SearchHandlerViewSummary && single:
+		ctx.JSON(http.StatusOK, summaries[0])
+	case w.view == SearchHandlerViewSummary:
+		ctx.JSON(http.StatusOK, summaries)
+	case single:
+		ctx.JSON(http.StatusOK, itineraries[0])
+	default:
+		ctx.JSON(http.StatusOK, itineraries)
+	}
+}
+
+// convert returns a copy of stored itinerary priced for passengers, with
+// names of airports and carriers.
+func (w *itineraryWriter) convert(itinerary *entities.Itinerary) (*entities.Itinerary, error) {
+	result := *itinerary
+	// prices without rate stay in the currency of the partner
+	if w.toCurrency != "" && !itinerary.NoBaseRate {
+		converted, err := w.currency.ConvertItinerary(itinerary, w.toCurrency)
+		if err != nil {
+			return nil, err
+		}
+		result = *converted
+	}
+
+	result.PartyPrice = result.GetPartyPrice(w.passengers)
+	result.Describe()
+	return &result, nil
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ParetoHandler struct{}
+
+// swagger:parameters ParetoHandlerQuery
+type ParetoHandlerQuery struct {
+	// Airport code, city code (e.g. LON for all London airports) or name, case-insensitive;
+	// matched airports are in X-Source-Airports header
+	// Required: true
+	Source string `json:"source" form:"source" binding:"required"`
+	// Airport code, city code or name like source; matched airports are in X-Destination-Airports header
+	// Required: true
+	Destination string `json:"destination" form:"destination" binding:"required"`
+	// Search exactly the source and destination airport codes, city codes and names aren't resolved
+	ExactAirports bool `json:"exactAirports" form:"exactAirports"`
+	ItineraryFilterQuery
+	// Possible view: full summary
+	View string `json:"view" form:"view" binding:"omitempty,oneof=full summary"`
+	// Currency code to convert prices to, e.g. USD; prices are in the currency of the partner when not set
+	Currency string `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	// Use number of stops as the third criterion
+	WithStops bool `json:"withStops" form:"withStops"`
+}
+
+func (s *ParetoHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query ParetoHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+	filter, err := query.GetFilter()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	writer := newItineraryWriter(services, query.View, query.Currency, filter.GetPassengers())
+	if err = writer.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sources, destinations, ok := resolveAirports(ctx, query.Source, query.Destination, query.ExactAirports)
+	if !ok {
+		return
+	}
+
+	itineraries, err := services.Storage().GetItineraries(sources, destinations, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	front := scoring.GetParetoFront(entities.GetBasePriced(itineraries), filter.GetPassengers(), query.WithStops)
+	writer.writeAll(ctx, front)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AirportsHandler struct{}
+
+// swagger:parameters AirportsHandlerQuery
+type AirportsHandlerQuery struct {
+	// Airport code, city code or name, case-insensitive; all airports when not set
+	Query string `json:"query" form:"query"`
+	// Airports of loaded itineraries only
+	Loaded bool `json:"loaded" form:"loaded"`
+}
+
+func (s *AirportsHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query AirportsHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	result, err := services.Reference().GetAirports(query.Query, query.Loaded)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+type AirlinesHandler struct{}
+
+// swagger:parameters AirlinesHandlerQuery
+type AirlinesHandlerQuery struct {
+	// Airlines of loaded itineraries only
+	Loaded bool `json:"loaded" form:"loaded"`
+}
+
+func (s *AirlinesHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query AirlinesHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	result, err := services.Reference().GetAirlines(query.Loaded)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/pkg/entities"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ResponsesHandler struct{}
+
+func (s *ResponsesHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	responses, err := services.Storage().GetResponses()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, responses)
+}
+
+type ResponseHandler struct{}
+
+// swagger:parameters ResponseHandlerQuery
+type ResponseHandlerQuery struct {
+	// in: path
+	// Required: true
+	ID string `json:"id" uri:"id" binding:"required"`
+}
+
+type ResponseHandlerResult struct {
+	*entities.SearchResponse
+	Itineraries []*entities.Itinerary
+}
+
+func (s *ResponseHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query ResponseHandlerQuery
+	if err := ctx.ShouldBindUri(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+
+	response, err := services.Storage().GetResponse(query.ID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	result := &ResponseHandlerResult{
+		SearchResponse: response,
+		Itineraries:    []*entities.Itinerary{},
+	}
+	if itineraries, err := services.Storage().GetResponseItineraries(query.ID); err == nil {
+		result.Itineraries = itineraries.Itineraries
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoutesHandler struct{}
+
+// swagger:parameters RoutesHandlerQuery
+type RoutesHandlerQuery struct {
+	// Routes from the airport code, city code or name; all routes when not set
+	Source string `json:"source" form:"source"`
+	// Routes to the airport code, city code or name; all routes when not set
+	Destination string `json:"destination" form:"destination"`
+	// Use exactly the source and destination airport codes
+	ExactAirports bool `json:"exactAirports" form:"exactAirports"`
+	// Number of routes to skip
+	Offset int `json:"offset" form:"offset" binding:"omitempty,min=0"`
+	// Max number of routes, all when not set; total count is in X-Total-Count header
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=0"`
+}
+
+func (s *RoutesHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query RoutesHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+	sources, err := resolveOptionalAirport(query.Source, query.ExactAirports)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	destinations, err := resolveOptionalAirport(query.Destination, query.ExactAirports)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	routes, err := services.Storage().GetRoutes()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	result := []*storage.Route{}
+	for _, route := range routes {
+		if (sources == nil || sources[route.Source]) && (destinations == nil || destinations[route.Destination]) {
+			result = append(result, route)
+		}
+	}
+
+	ctx.Header(totalCountHeader, strconv.Itoa(len(result)))
+	start, end := paginate(len(result), query.Offset, query.Limit)
+	ctx.JSON(http.StatusOK, result[start:end])
+}
+
+// resolveOptionalAirport returns nil for empty query, meaning any airport.
+func resolveOptionalAirport(query string, exact bool) (map[string]bool, error) {
+	if query == "" {
+		return nil, nil
+	}
+	codes, err := resolveAirport(query, exact)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		result[code] = true
+	}
+	return result, nil
+}
@@ -2,8 +2,15 @@ package handlers
 
 import (
 	"aviasales/internal/services"
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/airports"
 	"aviasales/pkg/entities"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,16 +23,227 @@ const (
 	SearchHandlerTypeOptimal       = "optimal"
 )
 
+const (
+	SearchHandlerOrderAsc  = "asc"
+	SearchHandlerOrderDesc = "desc"
+)
+
+const (
+	SearchHandlerViewFull    = "full"
+	SearchHandlerViewSummary = "summary"
+)
+
+const (
+	totalCountHeader          = "X-Total-Count"
+	sourceAirportsHeader      = "X-Source-Airports"
+	destinationAirportsHeader = "X-Destination-Airports"
+)
+
+var ErrUnknownAirport = errors.New("unknown airport")
+
 type SearchHandler struct{}
 
 // swagger:parameters SearchHandlerQuery
 type SearchHandlerQuery struct {
+	// Airport code, city code (e.g. LON for all London airports) or name, case-insensitive;
+	// matched airports are in X-Source-Airports header
 	// Required: true
 	Source string `json:"source" form:"source" binding:"required"`
+	// Airport code, city code or name like source; matched airports are in X-Destination-Airports header
 	// Required: true
 	Destination string `json:"destination" form:"destination" binding:"required"`
+	// Search exactly the source and destination airport codes, city codes and names aren't resolved
+	ExactAirports bool `json:"exactAirports" form:"exactAirports"`
 	// Possible type: cheapest mostExpensive longest shortest optimal
 	Type string `json:"type" form:"type" binding:"omitempty,oneof=cheapest mostExpensive longest shortest optimal"`
+	ItineraryFilterQuery
+	// Possible sort: price duration departure arrival stops
+	Sort string `json:"sort" form:"sort" binding:"omitempty,oneof=price duration departure arrival stops"`
+	// Possible order: asc desc
+	Order string `json:"order" form:"order" binding:"omitempty,oneof=asc desc"`
+	// Number of itineraries to skip, with type and limit set it's of the best itineraries
+	Offset int `json:"offset" form:"offset" binding:"omitempty,min=0"`
+	// Max number of itineraries, all when not set; total count is in X-Total-Count header.
+	// With type set it's a number of the best itineraries instead of a single one
+	Limit int `json:"limit" form:"limit" binding:"omitempty,min=0"`
+	// Possible view: full summary
+	View string `json:"view" form:"view" binding:"omitempty,oneof=full summary"`
+	// Currency code to convert prices to, e.g. USD; prices are in the currency of the partner when not set
+	Currency string `json:"currency" form:"currency" binding:"omitempty,len=3"`
+	// Weight of price for optimal type; when any weight is set, unset ones are 0
+	WeightPrice *float64 `json:"weightPrice" form:"weightPrice" binding:"omitempty,min=0"`
+	// Weight of time in the air for optimal type
+	WeightFlightTime *float64 `json:"weightFlightTime" form:"weightFlightTime" binding:"omitempty,min=0"`
+	// Weight of time on the ground for optimal type
+	WeightLayoverTime *float64 `json:"weightLayoverTime" form:"weightLayoverTime" binding:"omitempty,min=0"`
+	// Weight of number of stops for optimal type
+	WeightStops *float64 `json:"weightStops" form:"weightStops" binding:"omitempty,min=0"`
+	// Weight of departure at uncomfortable hours for optimal type
+	WeightDepartureHour *float64 `json:"weightDepartureHour" form:"weightDepartureHour" binding:"omitempty,min=0"`
+}
+
+func (q *ItineraryFilterQuery) GetFilter() (*entities.ItineraryFilter, error) {
+	passengers := q.GetPassengers()
+	if err := passengers.Validate(); err != nil {
+		return nil, err
+	}
+
+	schedule := entities.ScheduleFilter{
+		DepartureDate: q.DepartureDate,
+		ArrivalBy:     q.ArrivalBy,
+	}
+	if q.DepartureTime != "" {
+		window, err := entities.ParseTimeWindow(q.DepartureTime)
+		if err != nil {
+			return nil, err
+		}
+		schedule.DepartureTime = window
+	}
+	if q.ArrivalTime != "" {
+		window, err := entities.ParseTimeWindow(q.ArrivalTime)
+		if err != nil {
+			return nil, err
+		}
+		schedule.ArrivalTime = window
+	}
+
+	return &entities.ItineraryFilter{
+		TripType:   q.TripType,
+		ReturnDate: q.ReturnDate,
+		Passengers: passengers,
+		Layovers: entities.LayoverFilter{
+			MaxStops:                   q.MaxStops,
+			ConnectionAirports:         splitCodes(q.ConnectionAirports),
+			ExcludedConnectionAirports: splitCodes(q.ExcludedConnectionAirports),
+			MinDuration:                q.MinLayover,
+			MaxDuration:                q.MaxLayover,
+			ExcludeOvernight:           q.ExcludeOvernightLayovers,
+			SameAirportOnly:            q.SameAirportTransfers,
+		},
+		Flights: entities.FlightFilter{
+			Carriers:          splitCodes(q.Carriers),
+			ExcludedCarriers:  splitCodes(q.ExcludedCarriers),
+			Classes:           splitCodes(q.Classes),
+			ETicketOnly:       q.ETicketOnly,
+			SingleCarrierOnly: q.SingleCarrier,
+		},
+		Schedule: schedule,
+	}, nil
+}
+
+// GetWeights returns nil when request doesn't override weights.
+func (q *SearchHandlerQuery) GetWeights() (*scoring.Weights, error) {
+	values := []*float64{q.WeightPrice, q.WeightFlightTime, q.WeightLayoverTime, q.WeightStops, q.WeightDepartureHour}
+	isSet := false
+	for _, value := range values {
+		isSet = isSet || value != nil
+	}
+	if !isSet {
+		return nil, nil
+	}
+
+	valueOrZero := func(value *float64) float64 {
+		if value == nil {
+			return 0
+		}
+		return *value
+	}
+	weights := &scoring.Weights{
+		Price:         valueOrZero(q.WeightPrice),
+		FlightTime:    valueOrZero(q.WeightFlightTime),
+		LayoverTime:   valueOrZero(q.WeightLayoverTime),
+		Stops:         valueOrZero(q.WeightStops),
+		DepartureHour: valueOrZero(q.WeightDepartureHour),
+	}
+	if err := weights.Validate(); err != nil {
+		return nil, err
+	}
+	return weights, nil
+}
+
+// resolveAirportPair returns airport codes of source and destination.
+func resolveAirportPair(source, destination string, exact bool) (sources, destinations []string, err error) {
+	sources, err = resolveAirport(source, exact)
+	if err != nil {
+		return nil, nil, err
+	}
+	destinations, err = resolveAirport(destination, exact)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sources, destinations, nil
+}
+
+// resolveAirport expands city codes and names to airports, unknown codes
+// are searched as they are, partners may fly to airports missing in the
+// reference data.
+func resolveAirport(query string, exact bool) ([]string, error) {
+	code := strings.ToUpper(strings.TrimSpace(query))
+	if exact {
+		return []string{code}, nil
+	}
+	if resolved := airports.Resolve(query); len(resolved) > 0 {
+		return airports.GetCodes(resolved), nil
+	}
+	if airports.IsCode(code) {
+		return []string{code}, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnknownAirport, query)
+}
+
+func (q *ItineraryFilterQuery) GetPassengers() entities.Passengers {
+	return entities.Passengers{
+		Adults:   q.Adults,
+		Children: q.Children,
+		Infants:  q.Infants,
+	}
+}
+
+// ItineraryFilterQuery are passengers and filters of itineraries shared by
+// search handlers.
+type ItineraryFilterQuery struct {
+	// Possible tripType: oneWay roundTrip
+	TripType string `json:"tripType" form:"tripType" binding:"omitempty,oneof=oneWay roundTrip"`
+	// Departure date of the return flight, format: 2006-01-02
+	ReturnDate time.Time `json:"returnDate" form:"returnDate" time_format:"2006-01-02"`
+	// Number of adults, a single adult when no passengers are set
+	Adults int `json:"adults" form:"adults" binding:"omitempty,min=0,max=9"`
+	// Number of children
+	Children int `json:"children" form:"children" binding:"omitempty,min=0,max=9"`
+	// Number of infants, can't exceed number of adults
+	Infants int `json:"infants" form:"infants" binding:"omitempty,min=0,max=9"`
+	// Max number of stops in each direction, 0 for direct flights
+	MaxStops *int `json:"maxStops" form:"maxStops" binding:"omitempty,min=0"`
+	// Allowed connection airports, comma separated
+	ConnectionAirports []string `json:"connectionAirports" form:"connectionAirports"`
+	// Excluded connection airports, comma separated
+	ExcludedConnectionAirports []string `json:"excludedConnectionAirports" form:"excludedConnectionAirports"`
+	// Min layover duration, e.g. 1h30m
+	MinLayover time.Duration `json:"minLayover" form:"minLayover" binding:"omitempty,min=0"`
+	// Max layover duration, e.g. 6h
+	MaxLayover time.Duration `json:"maxLayover" form:"maxLayover" binding:"omitempty,min=0"`
+	// Exclude layovers spanning midnight of the connection airport
+	ExcludeOvernightLayovers bool `json:"excludeOvernightLayovers" form:"excludeOvernightLayovers"`
+	// Exclude transfers between different airports
+	SameAirportTransfers bool `json:"sameAirportTransfers" form:"sameAirportTransfers"`
+	// Allowed carrier codes, comma separated
+	Carriers []string `json:"carriers" form:"carriers"`
+	// Excluded carrier codes, comma separated
+	ExcludedCarriers []string `json:"excludedCarriers" form:"excludedCarriers"`
+	// Allowed booking class letters, comma separated
+	Classes []string `json:"classes" form:"classes"`
+	// Electronic tickets only
+	ETicketOnly bool `json:"eTicketOnly" form:"eTicketOnly"`
+	// Itineraries operated by a single carrier only
+	SingleCarrier bool `json:"singleCarrier" form:"singleCarrier"`
+	// Local departure date, format: 2006-01-02
+	DepartureDate time.Time `json:"departureDate" form:"departureDate" time_format:"2006-01-02"`
+	// Local departure time: night morning afternoon evening or HH:MM-HH:MM
+	DepartureTime string `json:"departureTime" form:"departureTime"`
+	// Local arrival time: night morning afternoon evening or HH:MM-HH:MM
+	ArrivalTime string `json:"arrivalTime" form:"arrivalTime"`
+	// Latest local arrival time, format: 2006-01-02T15:04
+	ArrivalBy time.Time `json:"arrivalBy" form:"arrivalBy" time_format:"2006-01-02T15:04"`
 }
 
 func (s *SearchHandler) Process(
@@ -37,38 +255,153 @@ func (s *SearchHandler) Process(
 		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
 		return
 	}
+	filter, err := query.GetFilter()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	writer := newItineraryWriter(services, query.View, query.Currency, filter.GetPassengers())
+	if err = writer.validate(); err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
 
-	sourceCity, destinationCity := query.Source, query.Destination
+	sources, destinations, ok := resolveAirports(ctx, query.Source, query.Destination, query.ExactAirports)
+	if !ok {
+		return
+	}
+
+	if query.Type == SearchHandlerTypeOptimal {
+		s.processOptimal(ctx, services, &query, sources, destinations, filter, writer)
+		return
+	}
+
+	if query.Type != "" && query.Limit > 0 {
+		s.processTop(ctx, services, &query, sources, destinations, filter, writer)
+		return
+	}
 
 	if query.Type != "" {
 		var result *entities.Itinerary
-		var err error
 		switch query.Type {
 		case SearchHandlerTypeCheapest:
-			result, err = services.Storage().GetCheapest(sourceCity, destinationCity)
+			result, err = services.Storage().GetCheapest(sources, destinations, filter)
 		case SearchHandlerTypeMostExpensive:
-			result, err = services.Storage().GetMostExpensive(sourceCity, destinationCity)
+			result, err = services.Storage().GetMostExpensive(sources, destinations, filter)
 		case SearchHandlerTypeLongest:
-			result, err = services.Storage().GetLongest(sourceCity, destinationCity)
+			result, err = services.Storage().GetLongest(sources, destinations, filter)
 		case SearchHandlerTypeShortest:
-			result, err = services.Storage().GetShortest(sourceCity, destinationCity)
-		case SearchHandlerTypeOptimal:
-			result, err = services.Storage().GetOptimal(sourceCity, destinationCity)
+			result, err = services.Storage().GetShortest(sources, destinations, filter)
 		}
 
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 			return
 		}
-		ctx.JSON(http.StatusOK, result)
+		writer.write(ctx, result)
+		return
+	}
+
+	itineraries, err := services.Storage().GetItineraries(sources, destinations, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
-	itineraries, err := services.Storage().GetItineraries(query.Source, query.Destination)
+	sorted := make([]*entities.Itinerary, len(itineraries))
+	copy(sorted, itineraries)
+	if query.Sort != "" {
+		itinerarySort := &entities.ItinerarySort{
+			By:         query.Sort,
+			Descending: query.Order == SearchHandlerOrderDesc,
+			Passengers: filter.GetPassengers(),
+		}
+		itinerarySort.Sort(sorted)
+	}
+
+	ctx.Header(totalCountHeader, strconv.Itoa(len(sorted)))
+	start, end := paginate(len(sorted), query.Offset, query.Limit)
+
+	writer.writeAll(ctx, sorted[start:end])
+}
+
+// processTop responds with a page of the best itineraries of the ranking,
+// total count is of all matched itineraries like of the untyped search.
+func (s *SearchHandler) processTop(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+	query *SearchHandlerQuery,
+	sources, destinations []string,
+	filter *entities.ItineraryFilter,
+	writer *itineraryWriter,
+) {
+	all, err := services.Storage().GetItineraries(sources, destinations, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	itineraries, err := services.Storage().GetTop(sources, destinations, query.Type, query.Offset+query.Limit, filter)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
 		return
 	}
 
-	ctx.JSON(http.StatusOK, itineraries)
+	ctx.Header(totalCountHeader, strconv.Itoa(len(all)))
+	start, end := paginate(len(itineraries), query.Offset, query.Limit)
+	writer.writeAll(ctx, itineraries[start:end])
+}
+
+// processOptimal ranks all matched itineraries and explains the score of
+// the best one, or of a page of the best ones when limit is set.
+func (s *SearchHandler) processOptimal(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+	query *SearchHandlerQuery,
+	sources, destinations []string,
+	filter *entities.ItineraryFilter,
+	writer *itineraryWriter,
+) {
+	weights, err := query.GetWeights()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	strategy := services.Scoring()
+	if weights != nil {
+		strategy = scoring.NewWeighted(*weights)
+	}
+
+	itineraries, err := services.Storage().GetItineraries(sources, destinations, filter)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	ranked := strategy.Rank(entities.GetBasePriced(itineraries), filter.GetPassengers())
+	if query.Limit > 0 {
+		ctx.Header(totalCountHeader, strconv.Itoa(len(itineraries)))
+		start, end := paginate(len(ranked), query.Offset, query.Limit)
+		writer.writeRanked(ctx, ranked[start:end], false)
+		return
+	}
+
+	if len(ranked) == 0 {
+		ctx.JSON(http.StatusOK, nil)
+		return
+	}
+	writer.writeRanked(ctx, ranked[:1], true)
+}
+
+// resolveAirports writes matched airports to the headers and returns them,
+// or responds with an error.
+func resolveAirports(ctx *gin.Context, source, destination string, exact bool) (sources, destinations []string, ok bool) {
+	sources, destinations, err := resolveAirportPair(source, destination, exact)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return nil, nil, false
+	}
+
+	ctx.Header(sourceAirportsHeader, strings.Join(sources, ","))
+	ctx.Header(destinationAirportsHeader, strings.Join(destinations, ","))
+	return sources, destinations, true
 }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"aviasales/internal/config"
+	"aviasales/internal/services"
+	"aviasales/internal/services/parser"
+	"aviasales/pkg/entities"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// newServices loads a fixture into memory storage.
+func newServices(t *testing.T) services.IServiceFactory {
+	cfg := config.Default()
+	cfg.Currency.RatesFile = "../../../rates.json"
+	factory := services.NewServiceFactory(context.Background(), cfg)
+	_, err := factory.Parser().Parse(&parser.WorkerParserQueue{
+		Ctx:        context.Background(),
+		FileName:   "../../../fixtures/RS_Via-3.xml",
+		ResponseID: "RS_Via-3.xml",
+		Storage:    factory.Storage(),
+	})
+	assert.NoError(t, err)
+	return factory
+}
+
+// serve runs handler for the query and decodes its JSON body.
+func serve(t *testing.T, handler IHandler, factory services.IServiceFactory, query string, body interface{}) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+
+	handler.Process(ctx, factory)
+	if body != nil {
+		assert.NoError(t, json.Unmarshal(recorder.Body.Bytes(), body))
+	}
+	return recorder
+}
+
+func TestSearchHandler_TypedPagination(t *testing.T) {
+	factory := newServices(t)
+	defer factory.Parser().Flush()
+
+	items := map[string]struct {
+		query     string
+		bestQuery string
+		from, to  int
+	}{
+		"it should return the first page of the best itineraries": {
+			query:     "type=cheapest&limit=2&offset=0",
+			bestQuery: "type=cheapest&limit=3",
+			from:      0,
+			to:        2,
+		},
+		"it should skip offset best itineraries": {
+			query:     "type=cheapest&limit=2&offset=1",
+			bestQuery: "type=cheapest&limit=3",
+			from:      1,
+			to:        3,
+		},
+		"it should skip offset best itineraries of optimal type": {
+			query:     "type=optimal&limit=1&offset=5",
+			bestQuery: "type=optimal&limit=6",
+			from:      5,
+			to:        6,
+		},
+		"it should return nothing past the end": {
+			query:     "type=cheapest&limit=2&offset=1000",
+			bestQuery: "type=cheapest&limit=3",
+			from:      0,
+			to:        0,
+		},
+	}
+
+	for message, item := range items {
+		var best, page []*entities.Itinerary
+		serve(t, &SearchHandler{}, factory, "source=DXB&destination=BKK&"+item.bestQuery, &best)
+		recorder := serve(t, &SearchHandler{}, factory, "source=DXB&destination=BKK&"+item.query, &page)
+		assert.Equal(t, "200", recorder.Header().Get(totalCountHeader), message)
+		assert.Equal(t, getUUIDs(best[item.from:item.to]), getUUIDs(page), message)
+	}
+}
+
+func getUUIDs(itineraries []*entities.Itinerary) []entities.ItineraryUUID {
+	result := make([]entities.ItineraryUUID, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		result = append(result, itinerary.UUID)
+	}
+	return result
+}
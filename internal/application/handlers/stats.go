@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/stats"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StatsHandler struct{}
+
+// swagger:parameters StatsHandlerQuery
+type StatsHandlerQuery struct {
+	// Airport code, city code or name like of search
+	// Required: true
+	Source string `json:"source" form:"source" binding:"required"`
+	// Airport code, city code or name like of search
+	// Required: true
+	Destination string `json:"destination" form:"destination" binding:"required"`
+	// Use exactly the source and destination airport codes
+	ExactAirports bool `json:"exactAirports" form:"exactAirports"`
+	// Price and duration percentiles from 1 to 99, comma separated; 10,25,75,90 when not set
+	Percentiles []string `json:"percentiles" form:"percentiles"`
+	// Add stats of every source response
+	ByResponse bool `json:"byResponse" form:"byResponse"`
+}
+
+func (q *StatsHandlerQuery) GetPercentiles() ([]int, error) {
+	result := []int{}
+	for _, value := range splitCodes(q.Percentiles) {
+		percentile, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, stats.ErrInvalidPercentile
+		}
+		result = append(result, percentile)
+	}
+	return result, nil
+}
+
+func (s *StatsHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query StatsHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+	percentiles, err := query.GetPercentiles()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	sources, destinations, ok := resolveAirports(ctx, query.Source, query.Destination, query.ExactAirports)
+	if !ok {
+		return
+	}
+
+	result, err := services.Stats().GetPairStats(sources, destinations, stats.Options{
+		Percentiles: percentiles,
+		ByResponse:  query.ByResponse,
+	})
+	if err == stats.ErrInvalidPercentile {
+		ctx.JSON(http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
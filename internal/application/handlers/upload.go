@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"aviasales/internal/services"
+	"aviasales/internal/services/parser"
+	"aviasales/pkg/entities"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+)
+
+// maxUploadSize limits partner response body, fixtures are about 1MB.
+const maxUploadSize = 32 << 20
+
+var errUploadTooLarge = errors.New("response body is too large")
+
+type UploadResponseHandler struct{}
+
+// swagger:parameters UploadResponseHandlerQuery
+type UploadResponseHandlerQuery struct {
+	// Name of the partner response file, it's shown in responses list
+	FileName string `json:"fileName" form:"fileName"`
+	// Reject the whole response on the first parse error, invalid itineraries are skipped otherwise
+	Strict bool `json:"strict" form:"strict"`
+}
+
+func (s *UploadResponseHandler) Process(
+	ctx *gin.Context,
+	services services.IServiceFactory,
+) {
+	var query UploadResponseHandlerQuery
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		ctx.JSON(http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
+		return
+	}
+	if ctx.Request.ContentLength > maxUploadSize {
+		ctx.JSON(http.StatusRequestEntityTooLarge, errUploadTooLarge.Error())
+		return
+	}
+
+	// body is parsed while it's streamed, so it's not read into memory
+	body := &sizeLimitedReader{reader: ctx.Request.Body, left: maxUploadSize}
+	responseID := entities.ResponseID(uuid.NewV4().String())
+	response, err := services.Parser().Parse(&parser.WorkerParserQueue{
+		Ctx:        ctx.Request.Context(),
+		FileName:   query.FileName,
+		ResponseID: responseID,
+		Storage:    services.Storage(),
+		Reader:     body,
+		Strict:     query.Strict,
+	})
+	switch {
+	case err == parser.ErrPoolClosed:
+		ctx.JSON(http.StatusServiceUnavailable, err.Error())
+		return
+	case err != nil:
+		ctx.JSON(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	case body.isExceeded:
+		// the parser rejects the response, the error is listed in it
+		ctx.JSON(http.StatusRequestEntityTooLarge, response)
+		return
+	case response.Rejected:
+		ctx.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// sizeLimitedReader fails instead of silently truncating the body like io.LimitReader.
+type sizeLimitedReader struct {
+	reader     io.Reader
+	left       int64
+	isExceeded bool
+}
+
+func (r *sizeLimitedReader) Read(p []byte) (int, error) {
+	if r.left <= 0 {
+		var probe [1]byte
+		if n, err := r.reader.Read(probe[:]); n == 0 && err != nil {
+			return 0, err
+		}
+		r.isExceeded = true
+		return 0, errUploadTooLarge
+	}
+	if int64(len(p)) > r.left {
+		p = p[:r.left]
+	}
+	n, err := r.reader.Read(p)
+	r.left -= int64(n)
+	return n, err
+}
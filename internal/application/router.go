@@ -1,6 +1,7 @@
 package application
 
 import (
+	"aviasales/internal/application/handlers"
 	"aviasales/internal/services"
 	"aviasales/pkg/logger"
 	"context"
@@ -47,11 +48,15 @@ func NewRouter(
 	ginRouter.GET("swagger.json", func(c *gin.Context) {
 		c.File("swagger.json")
 	})
+	// liveness doesn't depend on ingestion, readiness does
 	ginRouter.GET("/healthz", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "I'm ok",
 		})
 	})
+	ginRouter.GET("/readyz", func(c *gin.Context) {
+		(&handlers.ReadinessHandler{}).Process(c, services)
+	})
 
 	return &router{
 		ginRouter: ginRouter,
@@ -20,6 +20,54 @@ var routes = []*route{
 		method:  http.MethodGet,
 		handler: &handlers.SearchHandler{},
 	},
+	// swagger:route GET /v1/search/pareto ParetoHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/search/pareto",
+		method:  http.MethodGet,
+		handler: &handlers.ParetoHandler{},
+	},
+	// swagger:route GET /v1/search/graph GraphSearchHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/search/graph",
+		method:  http.MethodGet,
+		handler: &handlers.GraphSearchHandler{},
+	},
+	// swagger:route GET /v1/routes RoutesHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/routes",
+		method:  http.MethodGet,
+		handler: &handlers.RoutesHandler{},
+	},
+	// swagger:route GET /v1/stats StatsHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/stats",
+		method:  http.MethodGet,
+		handler: &handlers.StatsHandler{},
+	},
+	// swagger:route GET /v1/airports AirportsHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/airports",
+		method:  http.MethodGet,
+		handler: &handlers.AirportsHandler{},
+	},
+	// swagger:route GET /v1/airlines AirlinesHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/airlines",
+		method:  http.MethodGet,
+		handler: &handlers.AirlinesHandler{},
+	},
 	// swagger:route GET /v1/compare CompareHandlerQuery
 	// Responses:
 	// 200
@@ -28,4 +76,52 @@ var routes = []*route{
 		method:  http.MethodGet,
 		handler: &handlers.CompareHandler{},
 	},
+	// swagger:route GET /v1/compare/responses CompareResponsesHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/compare/responses",
+		method:  http.MethodGet,
+		handler: &handlers.CompareResponsesHandler{},
+	},
+	// swagger:route GET /v1/responses ResponsesHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/responses",
+		method:  http.MethodGet,
+		handler: &handlers.ResponsesHandler{},
+	},
+	// swagger:route POST /v1/responses UploadResponseHandlerQuery
+	// Responses:
+	// 201
+	{
+		path:    "/v1/responses",
+		method:  http.MethodPost,
+		handler: &handlers.UploadResponseHandler{},
+	},
+	// swagger:route GET /v1/responses/{id} ResponseHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/responses/:id",
+		method:  http.MethodGet,
+		handler: &handlers.ResponseHandler{},
+	},
+	// swagger:route GET /v1/admin/files WatchedFilesHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/admin/files",
+		method:  http.MethodGet,
+		handler: &handlers.WatchedFilesHandler{},
+	},
+	// swagger:route GET /v1/admin/progress IngestionProgressHandlerQuery
+	// Responses:
+	// 200
+	{
+		path:    "/v1/admin/progress",
+		method:  http.MethodGet,
+		handler: &handlers.IngestionProgressHandler{},
+	},
 }
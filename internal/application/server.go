@@ -1,22 +1,18 @@
 package application
 
 import (
+	"aviasales/internal/config"
 	"aviasales/internal/services"
 	"aviasales/pkg/logger"
 	"context"
 	"fmt"
 	"net/http"
-	"sync"
 	"time"
 )
 
-const (
-	HTTPPort        = 8080
-	gracefulTimeOut = 10 * time.Second
-)
-
 type server struct {
 	ctx    context.Context
+	config config.Server
 	router *router
 }
 
@@ -26,44 +22,45 @@ func NewServer(
 ) *server {
 	return &server{
 		ctx:    ctx,
+		config: services.Config().Server,
 		router: NewRouter(ctx, services),
 	}
 }
 
-func (s *server) Run() {
+// Run serves until the context is done, it returns the listen error at once
+// when the server can't be started.
+func (s *server) Run() error {
 	ctx := logger.With(s.ctx, "app", "run")
-	addr := fmt.Sprintf("0.0.0.0:%d", HTTPPort)
+	addr := fmt.Sprintf("0.0.0.0:%d", s.config.HTTPPort)
 
 	srv := &http.Server{
 		Addr:    addr,
 		Handler: s.router.GetRouterHandler(),
 	}
 
-	wg := sync.WaitGroup{}
-
-	wg.Add(1)
+	// it's buffered, ErrServerClosed isn't received after shutdown
+	listenErr := make(chan error, 1)
 	go func() {
-		defer wg.Done()
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error(ctx, "error while ListenAndServe", err)
-		}
+		listenErr <- srv.ListenAndServe()
 	}()
 
+	select {
+	case err := <-listenErr:
+		logger.Error(ctx, "error while ListenAndServe", err)
+		return err
+	case <-s.ctx.Done():
+	}
+
 	// graceful shutdown
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		<-s.ctx.Done()
-		logger.Info(s.ctx, "Shutting down http server...")
-		sCtx, cancel := context.WithTimeout(context.Background(), gracefulTimeOut)
-		defer func() {
-			cancel()
-		}()
-		if err := srv.Shutdown(sCtx); err != nil {
-			logger.Error(ctx, "error while shutdown server", err)
-		}
+	logger.Info(s.ctx, "Shutting down http server...")
+	sCtx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.GracefulTimeout))
+	defer func() {
+		cancel()
 	}()
+	if err := srv.Shutdown(sCtx); err != nil {
+		logger.Error(ctx, "error while shutdown server", err)
+	}
 
-	wg.Wait()
 	logger.Info(ctx, "Server is shutdown")
+	return nil
 }
@@ -0,0 +1,519 @@
+package config
+
+import (
+	"aviasales/internal/services/graph"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/logger"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// envPrefix prefixes environment variables of all settings,
+// e.g. AVIA_HTTP_PORT.
+const envPrefix = "AVIA_"
+
+// maxGraphSegments limits route search, it grows exponentially with segments.
+const maxGraphSegments = 6
+
+var ErrInvalidConfig = errors.New("invalid config")
+
+// Config holds application settings. Settings are loaded from defaults,
+// a YAML or JSON file, environment variables and command-line flags,
+// every next source overrides the previous ones.
+type Config struct {
+	Server   Server          `yaml:"server"`
+	Log      Log             `yaml:"log"`
+	Parser   Parser          `yaml:"parser"`
+	Watch    Watch           `yaml:"watch"`
+	Currency Currency        `yaml:"currency"`
+	Storage  Storage         `yaml:"storage"`
+	Graph    Graph           `yaml:"graph"`
+	Scoring  scoring.Weights `yaml:"scoring"`
+	// PrintConfig prints the loaded config instead of running the app.
+	PrintConfig bool `yaml:"-"`
+}
+
+type Server struct {
+	HTTPPort        int      `yaml:"httpPort"`
+	GracefulTimeout Duration `yaml:"gracefulTimeout"`
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+}
+
+type Parser struct {
+	Workers int `yaml:"workers"`
+	// Strict rejects partner response files with any parse error.
+	Strict bool `yaml:"strict"`
+}
+
+type Watch struct {
+	// Directories are scanned for partner response files.
+	Directories []string `yaml:"directories"`
+	Interval    Duration `yaml:"interval"`
+}
+
+type Currency struct {
+	RatesFile string `yaml:"ratesFile"`
+}
+
+type Storage struct {
+	// Backend is memory or bolt, bolt keeps itineraries in FileName.
+	Backend  string `yaml:"backend"`
+	FileName string `yaml:"fileName"`
+}
+
+// Graph holds connection rules of routes built from stored flight legs.
+type Graph struct {
+	MinConnection Duration `yaml:"minConnection"`
+	MaxConnection Duration `yaml:"maxConnection"`
+	MaxSegments   int      `yaml:"maxSegments"`
+	// AirportMinConnections override minConnection at the airports, e.g. DXB: 1h30m.
+	AirportMinConnections map[string]Duration `yaml:"airportMinConnections"`
+}
+
+// Duration is written as "10s" instead of nanoseconds.
+type Duration time.Duration
+
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+func (d *Duration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			HTTPPort:        8080,
+			GracefulTimeout: Duration(10 * time.Second),
+		},
+		Log: Log{
+			Level: "debug",
+		},
+		Parser: Parser{
+			Workers: 5,
+		},
+		Watch: Watch{
+			Directories: []string{"./fixtures"},
+			Interval:    Duration(5 * time.Second),
+		},
+		Currency: Currency{
+			RatesFile: "./rates.json",
+		},
+		Storage: Storage{
+			Backend:  storage.BackendMemory,
+			FileName: "./avia.db",
+		},
+		Graph: Graph{
+			MinConnection:         Duration(45 * time.Minute),
+			MaxConnection:         Duration(24 * time.Hour),
+			MaxSegments:           4,
+			AirportMinConnections: map[string]Duration{},
+		},
+		Scoring: scoring.DefaultWeights,
+	}
+}
+
+// setting is a value which may be overridden by an environment variable
+// and a command-line flag, env is the name without envPrefix.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	// list values of a repeated flag are joined instead of overridden
+	list   bool
+	isBool bool
+	set    func(config *Config, value string) error
+}
+
+// flagValue collects values of a setting flag.
+type flagValue struct {
+	setting *setting
+	values  map[string][]string
+}
+
+func (v *flagValue) String() string {
+	return ""
+}
+
+func (v *flagValue) Set(value string) error {
+	v.values[v.setting.flag] = append(v.values[v.setting.flag], value)
+	return nil
+}
+
+func (v *flagValue) IsBoolFlag() bool {
+	return v.setting.isBool
+}
+
+var settings = []setting{
+	{
+		flag:  "http-port",
+		env:   "HTTP_PORT",
+		usage: "port of HTTP server",
+		set: func(c *Config, v string) error {
+			return setInt(&c.Server.HTTPPort, v)
+		},
+	},
+	{
+		flag:  "graceful-timeout",
+		env:   "GRACEFUL_TIMEOUT",
+		usage: "time to finish requests on shutdown",
+		set: func(c *Config, v string) error {
+			return setDuration(&c.Server.GracefulTimeout, v)
+		},
+	},
+	{
+		flag:  "log-level",
+		env:   "LOG_LEVEL",
+		usage: "log level: debug, info, warn or error",
+		set: func(c *Config, v string) error {
+			c.Log.Level = v
+			return nil
+		},
+	},
+	{
+		flag:  "parsers",
+		env:   "PARSERS",
+		usage: "number of parser workers",
+		set: func(c *Config, v string) error {
+			return setInt(&c.Parser.Workers, v)
+		},
+	},
+	{
+		flag:   "strict",
+		env:    "STRICT",
+		usage:  "reject partner responses with any parse error",
+		isBool: true,
+		set: func(c *Config, v string) error {
+			return setBool(&c.Parser.Strict, v)
+		},
+	},
+	{
+		flag:  "watch-dir",
+		env:   "WATCH_DIRS",
+		usage: "comma separated directories with partner responses",
+		list:  true,
+		set: func(c *Config, v string) error {
+			c.Watch.Directories = splitList(v)
+			return nil
+		},
+	},
+	{
+		flag:  "watch-interval",
+		env:   "WATCH_INTERVAL",
+		usage: "interval of scanning watched directories",
+		set: func(c *Config, v string) error {
+			return setDuration(&c.Watch.Interval, v)
+		},
+	},
+	{
+		flag:  "rates-file",
+		env:   "RATES_FILE",
+		usage: "JSON file with currency rates",
+		set: func(c *Config, v string) error {
+			c.Currency.RatesFile = v
+			return nil
+		},
+	},
+	{
+		flag:  "storage",
+		env:   "STORAGE",
+		usage: "storage backend: memory or bolt",
+		set: func(c *Config, v string) error {
+			c.Storage.Backend = v
+			return nil
+		},
+	},
+	{
+		flag:  "storage-file",
+		env:   "STORAGE_FILE",
+		usage: "file of persistent storage",
+		set: func(c *Config, v string) error {
+			c.Storage.FileName = v
+			return nil
+		},
+	},
+	{
+		flag:  "min-connection",
+		env:   "MIN_CONNECTION",
+		usage: "min connection time of routes built from flight legs",
+		set: func(c *Config, v string) error {
+			return setDuration(&c.Graph.MinConnection, v)
+		},
+	},
+	{
+		flag:  "max-connection",
+		env:   "MAX_CONNECTION",
+		usage: "max connection time of routes built from flight legs",
+		set: func(c *Config, v string) error {
+			return setDuration(&c.Graph.MaxConnection, v)
+		},
+	},
+	{
+		flag:  "max-segments",
+		env:   "MAX_SEGMENTS",
+		usage: "max number of segments of routes built from flight legs",
+		set: func(c *Config, v string) error {
+			return setInt(&c.Graph.MaxSegments, v)
+		},
+	},
+	{
+		flag:  "weight-price",
+		env:   "WEIGHT_PRICE",
+		usage: "scoring weight of price",
+		set: func(c *Config, v string) error {
+			return setFloat(&c.Scoring.Price, v)
+		},
+	},
+	{
+		flag:  "weight-flight-time",
+		env:   "WEIGHT_FLIGHT_TIME",
+		usage: "scoring weight of flight time",
+		set: func(c *Config, v string) error {
+			return setFloat(&c.Scoring.FlightTime, v)
+		},
+	},
+	{
+		flag:  "weight-layover-time",
+		env:   "WEIGHT_LAYOVER_TIME",
+		usage: "scoring weight of layover time",
+		set: func(c *Config, v string) error {
+			return setFloat(&c.Scoring.LayoverTime, v)
+		},
+	},
+	{
+		flag:  "weight-stops",
+		env:   "WEIGHT_STOPS",
+		usage: "scoring weight of stops",
+		set: func(c *Config, v string) error {
+			return setFloat(&c.Scoring.Stops, v)
+		},
+	},
+	{
+		flag:  "weight-departure-hour",
+		env:   "WEIGHT_DEPARTURE_HOUR",
+		usage: "scoring weight of departure hour",
+		set: func(c *Config, v string) error {
+			return setFloat(&c.Scoring.DepartureHour, v)
+		},
+	},
+}
+
+// Load reads config with precedence flags > environment > file > defaults.
+// The file is set by -config flag or AVIA_CONFIG variable.
+func Load(args []string, lookupEnv func(key string) (string, bool)) (*Config, error) {
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	fileName := flags.String("config", "", "YAML or JSON config file")
+	printConfig := flags.Bool("print-config", false, "print the loaded config and exit")
+	// values are applied after the file and the environment
+	values := map[string][]string{}
+	for i := range settings {
+		usage := settings[i].usage + " (env " + envPrefix + settings[i].env + ")"
+		flags.Var(&flagValue{setting: &settings[i], values: values}, settings[i].flag, usage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	config := Default()
+	if *fileName == "" {
+		*fileName, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+	if *fileName != "" {
+		if err := config.loadFile(*fileName); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range settings {
+		value, ok := lookupEnv(envPrefix + settings[i].env)
+		if !ok {
+			continue
+		}
+		if err := settings[i].set(config, value); err != nil {
+			return nil, fmt.Errorf("%w: %s%s: %v", ErrInvalidConfig, envPrefix, settings[i].env, err)
+		}
+	}
+	for i := range settings {
+		if _, ok := values[settings[i].flag]; !ok {
+			continue
+		}
+		flagValues := values[settings[i].flag]
+		value := flagValues[len(flagValues)-1]
+		if settings[i].list {
+			value = strings.Join(flagValues, ",")
+		}
+		if err := settings[i].set(config, value); err != nil {
+			return nil, fmt.Errorf("%w: -%s: %v", ErrInvalidConfig, settings[i].flag, err)
+		}
+	}
+
+	config.PrintConfig = *printConfig
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// loadFile overrides settings present in the file, JSON is read as YAML.
+func (c *Config) loadFile(fileName string) error {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	if err = yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidConfig, fileName, err)
+	}
+	return nil
+}
+
+func (c *Config) Validate() error {
+	var problems []string
+	if c.Server.HTTPPort <= 0 || c.Server.HTTPPort > 65535 {
+		problems = append(problems, "server.httpPort should be between 1 and 65535")
+	}
+	if c.Server.GracefulTimeout <= 0 {
+		problems = append(problems, "server.gracefulTimeout should be positive")
+	}
+	var level logger.Level
+	if err := level.Set(c.Log.Level); err != nil {
+		problems = append(problems, "log.level: "+err.Error())
+	}
+	if c.Parser.Workers <= 0 {
+		problems = append(problems, "parser.workers should be positive")
+	}
+	if c.Watch.Interval <= 0 {
+		problems = append(problems, "watch.interval should be positive")
+	}
+	for _, directory := range c.Watch.Directories {
+		if strings.TrimSpace(directory) == "" {
+			problems = append(problems, "watch.directories should not be empty")
+		}
+	}
+	switch c.Storage.Backend {
+	case storage.BackendMemory:
+	case storage.BackendBolt:
+		if c.Storage.FileName == "" {
+			problems = append(problems, "storage.fileName should be set for bolt backend")
+		}
+	default:
+		problems = append(problems, "storage.backend should be memory or bolt")
+	}
+	if c.Graph.MinConnection < 0 || c.Graph.MaxConnection <= c.Graph.MinConnection {
+		problems = append(problems, "graph.maxConnection should be longer than non-negative graph.minConnection")
+	}
+	if c.Graph.MaxSegments <= 0 || c.Graph.MaxSegments > maxGraphSegments {
+		problems = append(problems, fmt.Sprintf("graph.maxSegments should be between 1 and %d", maxGraphSegments))
+	}
+	for airport, minConnection := range c.Graph.AirportMinConnections {
+		if minConnection < 0 {
+			problems = append(problems, "graph.airportMinConnections."+airport+" should not be negative")
+		}
+	}
+	if err := c.Scoring.Validate(); err != nil {
+		problems = append(problems, "scoring: "+err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidConfig, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// GetGraphRules returns graph settings with uppercased airport codes.
+func (c *Config) GetGraphRules() graph.Rules {
+	rules := graph.Rules{
+		MinConnection:         time.Duration(c.Graph.MinConnection),
+		MaxConnection:         time.Duration(c.Graph.MaxConnection),
+		MaxSegments:           c.Graph.MaxSegments,
+		AirportMinConnections: make(map[string]time.Duration, len(c.Graph.AirportMinConnections)),
+	}
+	for airport, minConnection := range c.Graph.AirportMinConnections {
+		rules.AirportMinConnections[strings.ToUpper(airport)] = time.Duration(minConnection)
+	}
+	return rules
+}
+
+// GetLogLevel returns the level of a validated config.
+func (c *Config) GetLogLevel() logger.Level {
+	var level logger.Level
+	_ = level.Set(c.Log.Level)
+	return level
+}
+
+// Print writes config as YAML, the output may be used as a config file.
+func (c *Config) Print(w io.Writer) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func setInt(target *int, value string) error {
+	result, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	*target = result
+	return nil
+}
+
+func setFloat(target *float64, value string) error {
+	result, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return err
+	}
+	*target = result
+	return nil
+}
+
+func setBool(target *bool, value string) error {
+	result, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	*target = result
+	return nil
+}
+
+func setDuration(target *Duration, value string) error {
+	result, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	*target = Duration(result)
+	return nil
+}
+
+func splitList(value string) []string {
+	result := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	directory, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	yamlFile := filepath.Join(directory, "config.yaml")
+	assert.NoError(t, ioutil.WriteFile(yamlFile, []byte("server:\n  httpPort: 9000\nparser:\n  workers: 2\nwatch:\n  interval: 1m\n"), 0600))
+	jsonFile := filepath.Join(directory, "config.json")
+	assert.NoError(t, ioutil.WriteFile(jsonFile, []byte(`{"server": {"httpPort": 9001}, "scoring": {"price": 1}}`), 0600))
+	unknownFile := filepath.Join(directory, "unknown.yaml")
+	assert.NoError(t, ioutil.WriteFile(unknownFile, []byte("server:\n  port: 9000\n"), 0600))
+
+	items := map[string]struct {
+		args     []string
+		env      map[string]string
+		check    func(config *Config)
+		hasError bool
+	}{
+		"it should use defaults": {
+			check: func(config *Config) {
+				assert.Equal(t, Default(), config)
+			},
+		},
+		"it should read YAML file": {
+			args: []string{"-config", yamlFile},
+			check: func(config *Config) {
+				assert.Equal(t, 9000, config.Server.HTTPPort)
+				assert.Equal(t, 2, config.Parser.Workers)
+				assert.Equal(t, Duration(time.Minute), config.Watch.Interval)
+				assert.Equal(t, Default().Log, config.Log)
+			},
+		},
+		"it should read JSON file set by environment": {
+			env: map[string]string{"AVIA_CONFIG": jsonFile},
+			check: func(config *Config) {
+				assert.Equal(t, 9001, config.Server.HTTPPort)
+				assert.Equal(t, float64(1), config.Scoring.Price)
+				assert.Equal(t, float64(2), config.Scoring.FlightTime)
+			},
+		},
+		"it should override file by environment and environment by flags": {
+			args: []string{"-config", yamlFile, "-parsers", "3", "-strict", "-watch-dir", "a", "-watch-dir", "b,c"},
+			env:  map[string]string{"AVIA_HTTP_PORT": "9002", "AVIA_PARSERS": "4", "AVIA_WATCH_DIRS": "d"},
+			check: func(config *Config) {
+				assert.Equal(t, 9002, config.Server.HTTPPort)
+				assert.Equal(t, 3, config.Parser.Workers)
+				assert.True(t, config.Parser.Strict)
+				assert.Equal(t, []string{"a", "b", "c"}, config.Watch.Directories)
+			},
+		},
+		"it should reject unknown file settings": {
+			args:     []string{"-config", unknownFile},
+			hasError: true,
+		},
+		"it should reject malformed environment": {
+			env:      map[string]string{"AVIA_WATCH_INTERVAL": "often"},
+			hasError: true,
+		},
+		"it should reject invalid settings": {
+			args:     []string{"-log-level", "verbose", "-weight-price", "-1"},
+			hasError: true,
+		},
+	}
+
+	for message, item := range items {
+		env := item.env
+		config, err := Load(item.args, func(key string) (string, bool) {
+			value, ok := env[key]
+			return value, ok
+		})
+		if item.hasError {
+			assert.Error(t, err, message)
+			continue
+		}
+		if assert.NoError(t, err, message) {
+			item.check(config)
+		}
+	}
+}
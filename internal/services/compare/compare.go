@@ -0,0 +1,73 @@
+package compare
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PickCheapest      = "cheapest"
+	PickMostExpensive = "mostExpensive"
+	PickLongest       = "longest"
+	PickShortest      = "shortest"
+	PickOptimal       = "optimal"
+)
+
+type IService interface {
+	// CompareResponses converts prices to toCurrency when it's set.
+	CompareResponses(response1, response2, toCurrency string) (*ResponsesDiff, error)
+}
+
+// ResponsesDiff describes what changed between two search responses.
+// Itineraries are matched by their flights (see entities.Itinerary.GetFlightsKey).
+type ResponsesDiff struct {
+	Response1 string                `json:"response1"`
+	Response2 string                `json:"response2"`
+	Added     []*entities.Itinerary `json:"added"`
+	Removed   []*entities.Itinerary `json:"removed"`
+	Changed   []*ItineraryDiff      `json:"changed"`
+	Picks     []*PickDiff           `json:"picks"`
+}
+
+type ItineraryDiff struct {
+	FlightsKey     string              `json:"flightsKey"`
+	Before         *entities.Itinerary `json:"before"`
+	After          *entities.Itinerary `json:"after"`
+	FlightsChanged bool                `json:"flightsChanged"`
+	PriceDeltas    []*PriceDelta       `json:"priceDeltas"`
+}
+
+// PriceDelta is a change of TotalAmount for one passenger type.
+type PriceDelta struct {
+	Type           string          `json:"type"`
+	CurrencyBefore string          `json:"currencyBefore"`
+	CurrencyAfter  string          `json:"currencyAfter"`
+	Before         decimal.Decimal `json:"before"`
+	After          decimal.Decimal `json:"after"`
+	Delta          decimal.Decimal `json:"delta"`
+}
+
+type PickDiff struct {
+	Type    string              `json:"type"`
+	Before  *entities.Itinerary `json:"before"`
+	After   *entities.Itinerary `json:"after"`
+	Changed bool                `json:"changed"`
+}
+
+type service struct {
+	ctx      context.Context
+	storage  storage.IStorage
+	currency currency.IService
+}
+
+func New(ctx context.Context, storage storage.IStorage, currency currency.IService) *service {
+	return &service{
+		ctx:      ctx,
+		storage:  storage,
+		currency: currency,
+	}
+}
@@ -0,0 +1,207 @@
+package compare
+
+import (
+	"aviasales/pkg/entities"
+	"reflect"
+)
+
+var passengerTypes = []string{
+	entities.TypeSingleAdult,
+	entities.TypeSingleChild,
+	entities.TypeSingleInfant,
+}
+
+func (s *service) CompareResponses(response1, response2, toCurrency string) (*ResponsesDiff, error) {
+	itineraries1, err := s.getResponseItineraries(response1, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+	itineraries2, err := s.getResponseItineraries(response2, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ResponsesDiff{
+		Response1: response1,
+		Response2: response2,
+		Added:     []*entities.Itinerary{},
+		Removed:   []*entities.Itinerary{},
+		Changed:   []*ItineraryDiff{},
+		Picks:     comparePicks(itineraries1, itineraries2),
+	}
+
+	byKey1, keys1 := groupByFlightsKey(itineraries1.Itineraries)
+	byKey2, keys2 := groupByFlightsKey(itineraries2.Itineraries)
+	for _, key := range keys1 {
+		before, after := matchOffers(byKey1[key], byKey2[key])
+		for k := range before {
+			if k >= len(after) {
+				result.Removed = append(result.Removed, before[k])
+				continue
+			}
+			if diff := compareItineraries(key, before[k], after[k]); diff != nil {
+				result.Changed = append(result.Changed, diff)
+			}
+		}
+		if len(after) > len(before) {
+			result.Added = append(result.Added, after[len(before):]...)
+		}
+	}
+	for _, key := range keys2 {
+		if _, ok := byKey1[key]; !ok {
+			result.Added = append(result.Added, byKey2[key]...)
+		}
+	}
+
+	return result, nil
+}
+
+// getResponseItineraries returns response itineraries with prices in
+// toCurrency, or as they are when toCurrency is empty.
+func (s *service) getResponseItineraries(responseID, toCurrency string) (*entities.Itineraries, error) {
+	itineraries, err := s.storage.GetResponseItineraries(responseID)
+	if err != nil || toCurrency == "" {
+		return itineraries, err
+	}
+
+	converted := make(map[*entities.Itinerary]*entities.Itinerary, len(itineraries.Itineraries))
+	result := &entities.Itineraries{
+		Itineraries: make([]*entities.Itinerary, 0, len(itineraries.Itineraries)),
+	}
+	for _, itinerary := range itineraries.Itineraries {
+		convertedItinerary, convertErr := s.currency.ConvertItinerary(itinerary, toCurrency)
+		if convertErr != nil {
+			return nil, convertErr
+		}
+		converted[itinerary] = convertedItinerary
+		result.Itineraries = append(result.Itineraries, convertedItinerary)
+	}
+	result.Cheapest = converted[itineraries.Cheapest]
+	result.MostExpensive = converted[itineraries.MostExpensive]
+	result.Longest = converted[itineraries.Longest]
+	result.Shortest = converted[itineraries.Shortest]
+	result.Optimal = converted[itineraries.Optimal]
+
+	return result, nil
+}
+
+// groupByFlightsKey returns itineraries by flights key and keys in the order
+// they come, several offers, e.g. of different fares, may share flights.
+func groupByFlightsKey(itineraries []*entities.Itinerary) (map[string][]*entities.Itinerary, []string) {
+	result := make(map[string][]*entities.Itinerary, len(itineraries))
+	keys := make([]string, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		key := itinerary.GetFlightsKey()
+		if _, ok := result[key]; !ok {
+			keys = append(keys, key)
+		}
+		result[key] = append(result[key], itinerary)
+	}
+	return result, keys
+}
+
+// matchOffers orders offers with the same flights so the same offers come
+// at the same positions first, the rest are matched in the order they come.
+func matchOffers(before, after []*entities.Itinerary) ([]*entities.Itinerary, []*entities.Itinerary) {
+	afterByUUID := make(map[entities.ItineraryUUID]int, len(after))
+	for k, itinerary := range after {
+		afterByUUID[itinerary.GetContentUUID()] = k
+	}
+
+	matchedBefore := make([]*entities.Itinerary, 0, len(before))
+	matchedAfter := make([]*entities.Itinerary, 0, len(after))
+	restBefore := make([]*entities.Itinerary, 0, len(before))
+	isMatched := make(map[int]bool, len(after))
+	for _, itinerary := range before {
+		k, ok := afterByUUID[itinerary.GetContentUUID()]
+		if !ok || isMatched[k] {
+			restBefore = append(restBefore, itinerary)
+			continue
+		}
+		isMatched[k] = true
+		matchedBefore = append(matchedBefore, itinerary)
+		matchedAfter = append(matchedAfter, after[k])
+	}
+	for k, itinerary := range after {
+		if !isMatched[k] {
+			matchedAfter = append(matchedAfter, itinerary)
+		}
+	}
+
+	return append(matchedBefore, restBefore...), matchedAfter
+}
+
+// compareItineraries returns nil when itineraries with the same flights
+// have neither flight details nor prices changed.
+func compareItineraries(key string, before, after *entities.Itinerary) *ItineraryDiff {
+	diff := &ItineraryDiff{
+		FlightsKey:     key,
+		Before:         before,
+		After:          after,
+		FlightsChanged: !reflect.DeepEqual(before.Onward, after.Onward) || !reflect.DeepEqual(before.Return, after.Return),
+		PriceDeltas:    []*PriceDelta{},
+	}
+
+	currencyBefore, currencyAfter := getCurrency(before), getCurrency(after)
+	for _, passengerType := range passengerTypes {
+		priceBefore := before.GetPrice(entities.ChargeTypeTotalAmount, passengerType)
+		priceAfter := after.GetPrice(entities.ChargeTypeTotalAmount, passengerType)
+		if priceBefore.Equal(priceAfter) && currencyBefore == currencyAfter {
+			continue
+		}
+
+		diff.PriceDeltas = append(diff.PriceDeltas, &PriceDelta{
+			Type:           passengerType,
+			CurrencyBefore: currencyBefore,
+			CurrencyAfter:  currencyAfter,
+			Before:         priceBefore,
+			After:          priceAfter,
+			Delta:          priceAfter.Sub(priceBefore),
+		})
+	}
+
+	if !diff.FlightsChanged && len(diff.PriceDeltas) == 0 {
+		return nil
+	}
+
+	return diff
+}
+
+func comparePicks(itineraries1, itineraries2 *entities.Itineraries) []*PickDiff {
+	picks := []struct {
+		pickType      string
+		before, after *entities.Itinerary
+	}{
+		{PickCheapest, itineraries1.Cheapest, itineraries2.Cheapest},
+		{PickMostExpensive, itineraries1.MostExpensive, itineraries2.MostExpensive},
+		{PickLongest, itineraries1.Longest, itineraries2.Longest},
+		{PickShortest, itineraries1.Shortest, itineraries2.Shortest},
+		{PickOptimal, itineraries1.Optimal, itineraries2.Optimal},
+	}
+
+	result := make([]*PickDiff, 0, len(picks))
+	for _, pick := range picks {
+		result = append(result, &PickDiff{
+			Type:    pick.pickType,
+			Before:  pick.before,
+			After:   pick.after,
+			Changed: getFlightsKey(pick.before) != getFlightsKey(pick.after),
+		})
+	}
+
+	return result
+}
+
+func getFlightsKey(itinerary *entities.Itinerary) string {
+	if itinerary == nil {
+		return ""
+	}
+	return itinerary.GetFlightsKey()
+}
+
+func getCurrency(itinerary *entities.Itinerary) string {
+	if itinerary.Pricing == nil {
+		return ""
+	}
+	return itinerary.Pricing.Currency
+}
@@ -0,0 +1,139 @@
+package compare
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	response1 = "RS_Via-3.xml"
+	response2 = "RS_ViaOW.xml"
+)
+
+func newCurrency() currency.IService {
+	return currency.New(context.Background(), currency.NewStaticProvider(currency.Rates{
+		Base:  "SGD",
+		Rates: map[string]decimal.Decimal{"USD": decimal.NewFromFloat(0.5)},
+	}))
+}
+
+func newItinerary(responseID, flightNumber string, price float64) entities.Itinerary {
+	return entities.Itinerary{
+		ResponseID: entities.ResponseID(responseID),
+		Onward: []entities.Flight{
+			{
+				Carrier:            entities.Carrier{Code: "AI", Name: "AirIndia"},
+				FlightNumber:       flightNumber,
+				Source:             "DXB",
+				Destination:        "BKK",
+				DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 27, 00, 05, 00, 00, time.UTC)},
+				ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 27, 19, 35, 00, 00, time.UTC)},
+			},
+		},
+		Pricing: &entities.Price{
+			Currency: "SGD",
+			ServiceCharges: []entities.Charge{
+				{
+					ChargeType: entities.ChargeTypeTotalAmount,
+					Type:       entities.TypeSingleAdult,
+					Cost:       decimal.NewFromFloat(price),
+				},
+			},
+		},
+	}
+}
+
+func TestService_CompareResponses(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage(ctx, newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+	s.AddItinerary(newItinerary(response1, "996", 385.40))
+	s.AddItinerary(newItinerary(response1, "332", 382.70))
+	s.AddItinerary(newItinerary(response1, "333", 400))
+	s.AddItinerary(newItinerary(response2, "996", 390.40))
+	s.AddItinerary(newItinerary(response2, "332", 382.70))
+	s.AddItinerary(newItinerary(response2, "995", 300))
+
+	diff, err := New(ctx, s, newCurrency()).CompareResponses(response1, response2, "")
+	assert.NoError(t, err)
+
+	assert.Len(t, diff.Added, 1, "it should add flight 995")
+	assert.Equal(t, "995", diff.Added[0].Onward[0].FlightNumber)
+
+	assert.Len(t, diff.Removed, 1, "it should remove flight 333")
+	assert.Equal(t, "333", diff.Removed[0].Onward[0].FlightNumber)
+
+	assert.Len(t, diff.Changed, 1, "it should change price of flight 996")
+	assert.False(t, diff.Changed[0].FlightsChanged)
+	assert.Len(t, diff.Changed[0].PriceDeltas, 1)
+	assert.Equal(t, entities.TypeSingleAdult, diff.Changed[0].PriceDeltas[0].Type)
+	assert.Equal(t, 0, diff.Changed[0].PriceDeltas[0].Delta.Cmp(decimal.NewFromInt(5)))
+
+	for _, pick := range diff.Picks {
+		if pick.Type == PickCheapest {
+			assert.True(t, pick.Changed, "it should pick flight 995 as the cheapest")
+			assert.Equal(t, "995", pick.After.Onward[0].FlightNumber)
+		}
+	}
+}
+
+func TestService_CompareResponses_SameFlights(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage(ctx, newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+	newOffer := func(responseID, fareBasis string, price float64) entities.Itinerary {
+		itinerary := newItinerary(responseID, "996", price)
+		itinerary.Onward[0].FareBasis = fareBasis
+		return itinerary
+	}
+	s.AddItinerary(newOffer(response1, "ECO", 385.40))
+	s.AddItinerary(newOffer(response1, "FLEX", 500))
+	s.AddItinerary(newOffer(response2, "FLEX", 510))
+	s.AddItinerary(newOffer(response2, "ECO", 385.40))
+	s.AddItinerary(newOffer(response2, "BIZ", 900))
+	roundTrip := newItinerary(response2, "996", 385.40)
+	roundTrip.Return = roundTrip.Onward
+	roundTrip.Onward = []entities.Flight{{Carrier: roundTrip.Return[0].Carrier, FlightNumber: "995", Source: "BKK", Destination: "DXB"}}
+	s.AddItinerary(roundTrip)
+
+	diff, err := New(ctx, s, newCurrency()).CompareResponses(response1, response2, "")
+	assert.NoError(t, err)
+
+	assert.Empty(t, diff.Removed, "it should match every offer with the same flights")
+	if assert.Len(t, diff.Changed, 1, "it should change price of the same fare") {
+		assert.Equal(t, "FLEX", diff.Changed[0].After.Onward[0].FareBasis)
+		assert.Equal(t, 0, diff.Changed[0].PriceDeltas[0].Delta.Cmp(decimal.NewFromInt(10)))
+	}
+	assert.Len(t, diff.Added, 2, "it should add the other fare and the return flight")
+}
+
+func TestService_CompareResponses_UnknownResponse(t *testing.T) {
+	ctx := context.Background()
+	_, err := New(ctx, storage.NewMemoryStorage(ctx, newCurrency(), scoring.NewWeighted(scoring.DefaultWeights)), newCurrency()).CompareResponses(response1, response2, "")
+	assert.Error(t, err)
+}
+
+func TestService_CompareResponses_Currency(t *testing.T) {
+	ctx := context.Background()
+	s := storage.NewMemoryStorage(ctx, newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+	s.AddItinerary(newItinerary(response1, "996", 385.40))
+	dollars := newItinerary(response2, "996", 190)
+	dollars.Pricing.Currency = "USD"
+	s.AddItinerary(dollars)
+
+	diff, err := New(ctx, s, newCurrency()).CompareResponses(response1, response2, "SGD")
+	assert.NoError(t, err)
+
+	assert.Len(t, diff.Changed, 1, "it should change price of flight 996")
+	assert.Equal(t, "SGD", diff.Changed[0].PriceDeltas[0].CurrencyAfter)
+	assert.Equal(t, 0, diff.Changed[0].PriceDeltas[0].Delta.Cmp(decimal.NewFromFloat(-5.4)), "it should be -5.40 SGD")
+
+	_, err = New(ctx, s, newCurrency()).CompareResponses(response1, response2, "XXX")
+	assert.Error(t, err, "it should fail for unknown currency")
+}
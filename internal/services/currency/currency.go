@@ -0,0 +1,142 @@
+package currency
+
+import (
+	"aviasales/pkg/entities"
+	"aviasales/pkg/logger"
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// pricePlaces is a number of decimal places of converted prices.
+const pricePlaces = 2
+
+var ErrUnknownCurrency = errors.New("unknown currency")
+
+// Rates holds amounts of currencies for one unit of the base currency.
+type Rates struct {
+	Base  string                     `json:"base"`
+	Rates map[string]decimal.Decimal `json:"rates"`
+}
+
+type IRatesProvider interface {
+	GetRates(ctx context.Context) (*Rates, error)
+}
+
+type IService interface {
+	// GetBase returns currency prices are normalized to before comparing.
+	GetBase() string
+	// GetRate returns a multiplier converting amounts from one currency to another.
+	GetRate(from, to string) (decimal.Decimal, error)
+	Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error)
+	// ConvertItinerary returns a copy of itinerary with all charges in the currency.
+	ConvertItinerary(itinerary *entities.Itinerary, to string) (*entities.Itinerary, error)
+}
+
+type service struct {
+	ctx      context.Context
+	provider IRatesProvider
+	rates    *Rates
+	loadOnce sync.Once
+}
+
+func New(ctx context.Context, provider IRatesProvider) *service {
+	return &service{
+		ctx:      ctx,
+		provider: provider,
+	}
+}
+
+// getRates loads rates once, without rates only amounts in the same
+// currency can be converted.
+func (s *service) getRates() *Rates {
+	s.loadOnce.Do(func() {
+		rates, err := s.provider.GetRates(s.ctx)
+		if err != nil {
+			logger.Error(s.ctx, "unable to load currency rates", err)
+			rates = &Rates{}
+		}
+		s.rates = normalize(rates)
+	})
+	return s.rates
+}
+
+func (s *service) GetBase() string {
+	return s.getRates().Base
+}
+
+func (s *service) GetRate(from, to string) (decimal.Decimal, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return decimal.NewFromInt(1), nil
+	}
+
+	rates := s.getRates()
+	rateFrom, ok := rates.Rates[from]
+	if !ok {
+		return decimal.Zero, ErrUnknownCurrency
+	}
+	rateTo, ok := rates.Rates[to]
+	if !ok {
+		return decimal.Zero, ErrUnknownCurrency
+	}
+
+	return rateTo.Div(rateFrom), nil
+}
+
+func (s *service) Convert(amount decimal.Decimal, from, to string) (decimal.Decimal, error) {
+	rate, err := s.GetRate(from, to)
+	if err != nil {
+		return decimal.Zero, err
+	}
+	return amount.Mul(rate).Round(pricePlaces), nil
+}
+
+func (s *service) ConvertItinerary(itinerary *entities.Itinerary, to string) (*entities.Itinerary, error) {
+	result := *itinerary
+	if itinerary.Pricing == nil {
+		return &result, nil
+	}
+
+	rate, err := s.GetRate(itinerary.Pricing.Currency, to)
+	if err != nil {
+		return nil, err
+	}
+	baseRate, err := s.GetRate(to, s.GetBase())
+	if err != nil {
+		return nil, err
+	}
+
+	pricing := &entities.Price{
+		Currency:       strings.ToUpper(to),
+		ServiceCharges: make([]entities.Charge, len(itinerary.Pricing.ServiceCharges)),
+	}
+	for i, charge := range itinerary.Pricing.ServiceCharges {
+		charge.Cost = charge.Cost.Mul(rate).Round(pricePlaces)
+		pricing.ServiceCharges[i] = charge
+	}
+	result.Pricing = pricing
+	result.BaseRate = baseRate
+
+	return &result, nil
+}
+
+// normalize uppercases currency codes and adds the base currency to rates.
+func normalize(rates *Rates) *Rates {
+	result := &Rates{
+		Base:  strings.ToUpper(rates.Base),
+		Rates: make(map[string]decimal.Decimal, len(rates.Rates)+1),
+	}
+	for code, rate := range rates.Rates {
+		if rate.IsPositive() {
+			result.Rates[strings.ToUpper(code)] = rate
+		}
+	}
+	if result.Base != "" {
+		result.Rates[result.Base] = decimal.NewFromInt(1)
+	}
+	return result
+}
@@ -0,0 +1,70 @@
+package currency
+
+import (
+	"aviasales/pkg/entities"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newService() *service {
+	return New(context.Background(), NewStaticProvider(Rates{
+		Base: "sgd",
+		Rates: map[string]decimal.Decimal{
+			"USD": decimal.NewFromFloat(0.5),
+			"EUR": decimal.NewFromFloat(0.4),
+		},
+	}))
+}
+
+func TestService_Convert(t *testing.T) {
+	items := map[string]struct {
+		from, to string
+		expected decimal.Decimal
+		err      error
+	}{
+		"it should convert from the base currency": {
+			from: "SGD", to: "USD", expected: decimal.NewFromFloat(50),
+		},
+		"it should convert to the base currency": {
+			from: "usd", to: "SGD", expected: decimal.NewFromFloat(200),
+		},
+		"it should convert via the base currency": {
+			from: "EUR", to: "USD", expected: decimal.NewFromFloat(125),
+		},
+		"it should keep the same currency": {
+			from: "THB", to: "THB", expected: decimal.NewFromFloat(100),
+		},
+		"it should fail for unknown currency": {
+			from: "SGD", to: "THB", err: ErrUnknownCurrency,
+		},
+	}
+
+	s := newService()
+	for message, item := range items {
+		result, err := s.Convert(decimal.NewFromInt(100), item.from, item.to)
+		assert.True(t, errors.Is(err, item.err), message)
+		assert.Equal(t, 0, result.Cmp(item.expected), message)
+	}
+}
+
+func TestService_ConvertItinerary(t *testing.T) {
+	itinerary := &entities.Itinerary{
+		Pricing: &entities.Price{
+			Currency: "USD",
+			ServiceCharges: []entities.Charge{
+				{ChargeType: entities.ChargeTypeTotalAmount, Type: entities.TypeSingleAdult, Cost: decimal.NewFromFloat(100.01)},
+			},
+		},
+	}
+
+	result, err := newService().ConvertItinerary(itinerary, "eur")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", result.Pricing.Currency)
+	assert.Equal(t, "80.01", result.Pricing.ServiceCharges[0].Cost.String(), "it should round to cents")
+	assert.Equal(t, "100.01", itinerary.Pricing.ServiceCharges[0].Cost.String(), "it should keep stored itinerary")
+	assert.Equal(t, "200.025", result.GetBasePrice(entities.Passengers{}).String(), "it should compare in the base currency")
+}
@@ -0,0 +1,47 @@
+package currency
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+)
+
+type fileProvider struct {
+	fileName string
+}
+
+// NewFileProvider reads rates from a JSON file, see rates.json.
+func NewFileProvider(fileName string) *fileProvider {
+	return &fileProvider{
+		fileName: fileName,
+	}
+}
+
+func (p *fileProvider) GetRates(_ context.Context) (*Rates, error) {
+	data, err := ioutil.ReadFile(p.fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := &Rates{}
+	if err = json.Unmarshal(data, rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+type staticProvider struct {
+	rates Rates
+}
+
+// NewStaticProvider serves fixed rates, e.g. in tests.
+func NewStaticProvider(rates Rates) *staticProvider {
+	return &staticProvider{
+		rates: rates,
+	}
+}
+
+func (p *staticProvider) GetRates(_ context.Context) (*Rates, error) {
+	rates := p.rates
+	return &rates, nil
+}
@@ -0,0 +1,311 @@
+package graph
+
+import (
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidOptions = errors.New("invalid route options")
+
+// Rules are connection rules of the graph, they can be tightened by Options.
+type Rules struct {
+	MinConnection time.Duration
+	MaxConnection time.Duration
+	MaxSegments   int
+	// AirportMinConnections override MinConnection at the airports.
+	AirportMinConnections map[string]time.Duration
+}
+
+type Options struct {
+	// MinConnection is used when it's longer than the rule of the airport,
+	// legs booked together in a stored itinerary are checked by it only.
+	MinConnection time.Duration
+	// MaxConnection and MaxSegments are used when they are below the rules.
+	MaxConnection time.Duration
+	MaxSegments   int
+	// DepartureDate matches local date of the first segment.
+	DepartureDate time.Time
+}
+
+// Segment is a flight leg with stored itineraries containing it.
+type Segment struct {
+	Flight      entities.Flight          `json:"flight"`
+	Itineraries []entities.ItineraryUUID `json:"itineraries"`
+	// booked are legs following the segment in stored itineraries.
+	booked map[*Segment]struct{}
+}
+
+// Route is a chain of segments connected at the same airport.
+type Route struct {
+	Segments    []*Segment    `json:"segments"`
+	Departure   time.Time     `json:"departure"`
+	Arrival     time.Time     `json:"arrival"`
+	Duration    time.Duration `json:"duration"`
+	Connections []string      `json:"connections"`
+	// Itinerary is set when a stored itinerary contains all segments.
+	Itinerary entities.ItineraryUUID `json:"itinerary,omitempty"`
+}
+
+type IService interface {
+	// FindRoutes returns all routes from source to destination airport built
+	// from legs of stored itineraries, ordered by arrival and duration.
+	FindRoutes(source, destination string, options Options) ([]*Route, error)
+}
+
+type service struct {
+	ctx     context.Context
+	storage storage.IStorage
+	rules   Rules
+	// graph is built of the storage version, it's rebuilt after updates.
+	graph      *graph
+	version    uint64
+	isBuilding sync.Mutex
+}
+
+func New(ctx context.Context, storage storage.IStorage, rules Rules) *service {
+	return &service{
+		ctx:     ctx,
+		storage: storage,
+		rules:   rules,
+	}
+}
+
+func (s *service) FindRoutes(source, destination string, options Options) ([]*Route, error) {
+	source, destination = strings.ToUpper(source), strings.ToUpper(destination)
+	if source == destination || options.MinConnection < 0 || options.MaxConnection < 0 || options.MaxSegments < 0 {
+		return nil, ErrInvalidOptions
+	}
+
+	current, err := s.getGraph()
+	if err != nil {
+		return nil, err
+	}
+
+	search := &search{
+		graph:       current,
+		rules:       s.getRules(options),
+		minimum:     options.MinConnection,
+		destination: destination,
+		visited:     map[string]bool{source: true},
+		routes:      []*Route{},
+	}
+	for _, segment := range search.graph.departures[source] {
+		if options.DepartureDate.IsZero() || entities.IsSameDate(segment.Flight.DepartureTimeStamp.Time, options.DepartureDate) {
+			search.walk([]*Segment{segment})
+		}
+	}
+
+	sortRoutes(search.routes)
+	return search.routes, nil
+}
+
+// getGraph returns the graph of stored itineraries, it's built again when
+// the storage is changed since the last build.
+func (s *service) getGraph() (*graph, error) {
+	s.isBuilding.Lock()
+	defer s.isBuilding.Unlock()
+
+	// the version is taken first, an update during the build is caught by
+	// the next search
+	version := s.storage.GetVersion()
+	if s.graph != nil && s.version == version {
+		return s.graph, nil
+	}
+
+	itineraries, err := s.storage.GetAllItineraries()
+	if err != nil {
+		return nil, err
+	}
+	s.graph, s.version = newGraph(itineraries), version
+	return s.graph, nil
+}
+
+// getRules tightens graph rules by request options, min connection is
+// checked per airport.
+func (s *service) getRules(options Options) Rules {
+	rules := s.rules
+	if options.MaxConnection > 0 && options.MaxConnection < rules.MaxConnection {
+		rules.MaxConnection = options.MaxConnection
+	}
+	if options.MaxSegments > 0 && options.MaxSegments < rules.MaxSegments {
+		rules.MaxSegments = options.MaxSegments
+	}
+	return rules
+}
+
+// getMinConnection returns the airport rule or the default one, but not
+// shorter than requested.
+func (r Rules) getMinConnection(airport string, requested time.Duration) time.Duration {
+	minConnection, ok := r.AirportMinConnections[airport]
+	if !ok {
+		minConnection = r.MinConnection
+	}
+	if minConnection < requested {
+		return requested
+	}
+	return minConnection
+}
+
+// graph keeps unique legs by departure airport ordered by departure time,
+// it isn't changed after the build and is shared by searches.
+type graph struct {
+	departures map[string][]*Segment
+}
+
+func newGraph(itineraries []*entities.Itinerary) *graph {
+	segments := map[string]*Segment{}
+	result := &graph{departures: map[string][]*Segment{}}
+	for _, itinerary := range itineraries {
+		for _, flights := range [][]entities.Flight{itinerary.Onward, itinerary.Return} {
+			var previous *Segment
+			for i := range flights {
+				key := getLegKey(&flights[i])
+				segment, ok := segments[key]
+				if !ok {
+					segment = &Segment{Flight: flights[i], Itineraries: []entities.ItineraryUUID{}, booked: map[*Segment]struct{}{}}
+					segments[key] = segment
+					result.departures[flights[i].Source] = append(result.departures[flights[i].Source], segment)
+				}
+				segment.Itineraries = append(segment.Itineraries, itinerary.UUID)
+				if previous != nil {
+					previous.booked[segment] = struct{}{}
+				}
+				previous = segment
+			}
+		}
+	}
+
+	for airport := range result.departures {
+		departures := result.departures[airport]
+		sort.Slice(departures, func(i, j int) bool {
+			return lessSegment(departures[i], departures[j])
+		})
+	}
+	for _, segment := range segments {
+		sort.Slice(segment.Itineraries, func(i, j int) bool {
+			return segment.Itineraries[i] < segment.Itineraries[j]
+		})
+	}
+	return result
+}
+
+// getLegKey identifies the same leg in different itineraries.
+func getLegKey(flight *entities.Flight) string {
+	return strings.Join([]string{
+		flight.Carrier.Code,
+		flight.FlightNumber,
+		flight.Source,
+		flight.Destination,
+		flight.DepartureTimeStamp.Format(time.RFC3339),
+	}, "|")
+}
+
+type search struct {
+	graph *graph
+	rules Rules
+	// minimum is the requested min connection
+	minimum     time.Duration
+	destination string
+	visited     map[string]bool
+	routes      []*Route
+}
+
+// walk extends the path with connecting segments, airports aren't visited
+// twice. Legs booked together skip the min connection rules, but not the
+// requested one.
+func (s *search) walk(path []*Segment) {
+	lastSegment := path[len(path)-1]
+	last := lastSegment.Flight
+	if last.Destination == s.destination {
+		s.routes = append(s.routes, newRoute(path))
+		return
+	}
+	if len(path) >= s.rules.MaxSegments || s.visited[last.Destination] {
+		return
+	}
+
+	s.visited[last.Destination] = true
+	defer delete(s.visited, last.Destination)
+
+	minConnection := s.rules.getMinConnection(last.Destination, s.minimum)
+	earliest := last.ArrivalTimeStamp.Add(s.minimum)
+	connecting := last.ArrivalTimeStamp.Add(minConnection)
+	latest := last.ArrivalTimeStamp.Add(s.rules.MaxConnection)
+	departures := s.graph.departures[last.Destination]
+	start := sort.Search(len(departures), func(i int) bool {
+		return !departures[i].Flight.DepartureTimeStamp.Before(earliest)
+	})
+	for _, segment := range departures[start:] {
+		if segment.Flight.DepartureTimeStamp.After(latest) {
+			break
+		}
+		if _, ok := lastSegment.booked[segment]; !ok && segment.Flight.DepartureTimeStamp.Before(connecting) {
+			continue
+		}
+		next := make([]*Segment, len(path), len(path)+1)
+		copy(next, path)
+		s.walk(append(next, segment))
+	}
+}
+
+func newRoute(path []*Segment) *Route {
+	first, last := path[0].Flight, path[len(path)-1].Flight
+	route := &Route{
+		Segments:    path,
+		Departure:   first.DepartureTimeStamp.Time,
+		Arrival:     last.ArrivalTimeStamp.Time,
+		Duration:    last.ArrivalTimeStamp.Sub(first.DepartureTimeStamp.Time),
+		Connections: []string{},
+	}
+	for _, segment := range path[1:] {
+		route.Connections = append(route.Connections, segment.Flight.Source)
+	}
+	route.Itinerary = getCommonItinerary(path)
+	return route
+}
+
+// getCommonItinerary returns a stored itinerary containing all segments of
+// the path, legs of a booked itinerary are sold together.
+func getCommonItinerary(path []*Segment) entities.ItineraryUUID {
+	for _, uuid := range path[0].Itineraries {
+		common := true
+		for _, segment := range path[1:] {
+			position := sort.Search(len(segment.Itineraries), func(i int) bool {
+				return segment.Itineraries[i] >= uuid
+			})
+			if position == len(segment.Itineraries) || segment.Itineraries[position] != uuid {
+				common = false
+				break
+			}
+		}
+		if common {
+			return uuid
+		}
+	}
+	return ""
+}
+
+func sortRoutes(routes []*Route) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if !routes[i].Arrival.Equal(routes[j].Arrival) {
+			return routes[i].Arrival.Before(routes[j].Arrival)
+		}
+		if routes[i].Duration != routes[j].Duration {
+			return routes[i].Duration < routes[j].Duration
+		}
+		return len(routes[i].Segments) < len(routes[j].Segments)
+	})
+}
+
+func lessSegment(a, b *Segment) bool {
+	if !a.Flight.DepartureTimeStamp.Equal(b.Flight.DepartureTimeStamp.Time) {
+		return a.Flight.DepartureTimeStamp.Before(b.Flight.DepartureTimeStamp.Time)
+	}
+	return getLegKey(&a.Flight) < getLegKey(&b.Flight)
+}
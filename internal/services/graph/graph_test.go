@@ -0,0 +1,159 @@
+package graph
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlight(number, source, destination string, departure, arrival time.Time) entities.Flight {
+	return entities.Flight{
+		Carrier:            entities.Carrier{Code: "EK"},
+		FlightNumber:       number,
+		Source:             source,
+		Destination:        destination,
+		DepartureTimeStamp: entities.FlightDate{Time: departure},
+		ArrivalTimeStamp:   entities.FlightDate{Time: arrival},
+	}
+}
+
+func at(day, hour, minute int) time.Time {
+	return time.Date(2018, 10, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestService_FindRoutes(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(
+		ctx,
+		currency.New(ctx, currency.NewStaticProvider(currency.Rates{})),
+		scoring.NewWeighted(scoring.DefaultWeights),
+	)
+	dxbDel := newFlight("1", "DXB", "DEL", at(27, 0, 0), at(27, 4, 0))
+	delBkk := newFlight("2", "DEL", "BKK", at(27, 13, 0), at(27, 19, 0))
+	dxbCan := newFlight("3", "DXB", "CAN", at(27, 1, 0), at(27, 12, 0))
+	canBkk := newFlight("4", "CAN", "BKK", at(27, 12, 30), at(27, 15, 0))
+	delCan := newFlight("5", "DEL", "CAN", at(27, 5, 0), at(27, 11, 0))
+	for _, flights := range [][]entities.Flight{{dxbDel, delBkk}, {dxbCan, canBkk}, {delCan}} {
+		assert.NoError(t, store.AddItinerary(entities.Itinerary{ResponseID: "response", Onward: flights}))
+	}
+
+	s := New(ctx, store, Rules{
+		MinConnection:         time.Hour,
+		MaxConnection:         24 * time.Hour,
+		MaxSegments:           3,
+		AirportMinConnections: map[string]time.Duration{"CAN": 30 * time.Minute},
+	})
+
+	items := map[string]struct {
+		source      string
+		destination string
+		options     Options
+		expected    [][]string
+		common      []bool
+	}{
+		"it should find legs ending inside stored itineraries": {
+			source:      "DXB",
+			destination: "DEL",
+			expected:    [][]string{{"1"}},
+			common:      []bool{true},
+		},
+		"it should find legs starting inside stored itineraries": {
+			source:      "del",
+			destination: "bkk",
+			expected:    [][]string{{"5", "4"}, {"2"}},
+			common:      []bool{false, true},
+		},
+		"it should connect legs of different itineraries": {
+			source:      "DXB",
+			destination: "BKK",
+			expected:    [][]string{{"3", "4"}, {"1", "5", "4"}, {"1", "2"}},
+			common:      []bool{true, false, true},
+		},
+		"it should respect requested min connection": {
+			source:      "DXB",
+			destination: "BKK",
+			options:     Options{MinConnection: 45 * time.Minute},
+			expected:    [][]string{{"1", "5", "4"}, {"1", "2"}},
+			common:      []bool{false, true},
+		},
+		"it should respect segments cap": {
+			source:      "DXB",
+			destination: "BKK",
+			options:     Options{MaxSegments: 2},
+			expected:    [][]string{{"3", "4"}, {"1", "2"}},
+			common:      []bool{true, true},
+		},
+		"it should filter by departure date": {
+			source:      "DXB",
+			destination: "BKK",
+			options:     Options{DepartureDate: at(28, 0, 0)},
+			expected:    [][]string{},
+			common:      []bool{},
+		},
+	}
+
+	for message, item := range items {
+		routes, err := s.FindRoutes(item.source, item.destination, item.options)
+		assert.NoError(t, err, message)
+
+		numbers := [][]string{}
+		common := []bool{}
+		for _, route := range routes {
+			routeNumbers := []string{}
+			for _, segment := range route.Segments {
+				routeNumbers = append(routeNumbers, segment.Flight.FlightNumber)
+			}
+			numbers = append(numbers, routeNumbers)
+			common = append(common, route.Itinerary != "")
+		}
+		assert.Equal(t, item.expected, numbers, message)
+		assert.Equal(t, item.common, common, message)
+	}
+
+	_, err := s.FindRoutes("DXB", "dxb", Options{})
+	assert.Equal(t, ErrInvalidOptions, err)
+}
+
+func TestService_FindRoutes_Updates(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(
+		ctx,
+		currency.New(ctx, currency.NewStaticProvider(currency.Rates{})),
+		scoring.NewWeighted(scoring.DefaultWeights),
+	)
+	dxbKhi := newFlight("1", "DXB", "KHI", at(27, 0, 0), at(27, 3, 0))
+	khiBkk := newFlight("2", "KHI", "BKK", at(27, 3, 20), at(27, 9, 0))
+	khiBkkLate := newFlight("3", "KHI", "BKK", at(27, 3, 30), at(27, 9, 30))
+	assert.NoError(t, store.AddItinerary(entities.Itinerary{ResponseID: "booked", Onward: []entities.Flight{dxbKhi, khiBkk}}))
+	assert.NoError(t, store.AddItinerary(entities.Itinerary{ResponseID: "booked", Onward: []entities.Flight{dxbKhi}}))
+
+	s := New(ctx, store, Rules{MinConnection: time.Hour, MaxConnection: 24 * time.Hour, MaxSegments: 3})
+
+	routes, err := s.FindRoutes("DXB", "BKK", Options{})
+	assert.NoError(t, err)
+	if assert.Len(t, routes, 1, "it should skip min connection of legs booked together") {
+		assert.Equal(t, "2", routes[0].Segments[1].Flight.FlightNumber)
+	}
+	routes, err = s.FindRoutes("DXB", "BKK", Options{MinConnection: 30 * time.Minute})
+	assert.NoError(t, err)
+	assert.Empty(t, routes, "it should respect requested min connection of legs booked together")
+
+	assert.NoError(t, store.AddItinerary(entities.Itinerary{ResponseID: "separate", Onward: []entities.Flight{khiBkkLate}}))
+	routes, err = s.FindRoutes("KHI", "BKK", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, routes, 2, "it should find legs added after the previous search")
+	routes, err = s.FindRoutes("DXB", "BKK", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, routes, 1, "it should keep min connection of legs of different itineraries")
+
+	assert.NoError(t, store.RemoveResponse("booked"))
+	routes, err = s.FindRoutes("DXB", "BKK", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, routes, "it should drop legs of removed responses")
+}
@@ -0,0 +1,359 @@
+package parser
+
+import (
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"aviasales/pkg/logger"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// itinerariesBatchSize limits itineraries stored at once in lenient mode.
+const itinerariesBatchSize = 500
+
+var ErrPoolClosed = errors.New("parser pool is closed")
+
+type IPool interface {
+	Put(queue *WorkerParserQueue) error
+	// Parse queues reader and waits until it's parsed, when the context is
+	// canceled it waits until the worker stops.
+	Parse(queue *WorkerParserQueue) (*entities.SearchResponse, error)
+	Flush()
+}
+
+type WorkerParserQueue struct {
+	Ctx        context.Context
+	FileName   string
+	ResponseID entities.ResponseID
+	Storage    storage.IStorage
+	// Reader is parsed instead of FileName when it's set, e.g. an uploaded body.
+	Reader io.Reader
+	// Done receives the response when it's parsed, it should be buffered.
+	Done chan<- entities.SearchResponse
+	// Strict rejects the whole response on the first parse error.
+	Strict bool
+}
+
+// SpawnWorkers creates <count> workers that process messages.
+func SpawnWorkers(
+	workersLimit int,
+) *WorkerPool {
+	ret := &WorkerPool{
+		ch: make(chan WorkerParserQueue, workersLimit),
+		wg: &sync.WaitGroup{},
+	}
+
+	ret.wg.Add(workersLimit)
+
+	for i := 0; i < workersLimit; i++ {
+		w := worker{}
+		go w.runLoop(ret.ch, ret.wg)
+	}
+	return ret
+}
+
+// WorkerPool processes Daemon SendMessage's
+// until Flush() is called.
+type WorkerPool struct {
+	wg *sync.WaitGroup
+	ch chan WorkerParserQueue
+	// isClosing is held for reading while queues are sent, so the channel
+	// isn't closed under them.
+	isClosing sync.RWMutex
+	isClosed  bool
+}
+
+func (w *WorkerPool) Flush() {
+	w.isClosing.Lock()
+	defer w.isClosing.Unlock()
+
+	if !w.isClosed {
+		close(w.ch)
+		w.wg.Wait()
+		w.isClosed = true
+	}
+}
+
+// Put waits for a free worker until the queue context is canceled.
+func (w *WorkerPool) Put(queue *WorkerParserQueue) error {
+	w.isClosing.RLock()
+	defer w.isClosing.RUnlock()
+
+	if w.isClosed {
+		return ErrPoolClosed
+	}
+	select {
+	case w.ch <- *queue:
+		return nil
+	case <-queue.Ctx.Done():
+		return queue.Ctx.Err()
+	}
+}
+
+func (w *WorkerPool) Parse(queue *WorkerParserQueue) (*entities.SearchResponse, error) {
+	done := make(chan entities.SearchResponse, 1)
+	queue.Done = done
+	if err := w.Put(queue); err != nil {
+		return nil, err
+	}
+
+	select {
+	case response := <-done:
+		return &response, nil
+	case <-queue.Ctx.Done():
+		// the worker stops on the next token and removes stored itineraries
+		<-done
+		return nil, queue.Ctx.Err()
+	}
+}
+
+type worker struct{}
+
+func (w *worker) runLoop(
+	in <-chan WorkerParserQueue,
+	wg *sync.WaitGroup,
+) {
+	defer wg.Done()
+
+	for queue := range in {
+		response := w.processQueue(&queue)
+		if queue.Done != nil {
+			queue.Done <- response
+		}
+	}
+}
+
+// processQueue skips invalid itineraries in lenient mode, in strict mode
+// itineraries are stored only when the whole response is valid. Itineraries
+// are stored by batches, they are removed when the response is canceled or
+// can't be read to the end.
+func (w *worker) processQueue(queue *WorkerParserQueue) (response entities.SearchResponse) {
+	ctx := logger.With(queue.Ctx, "fileName", queue.FileName, "responseID", queue.ResponseID)
+
+	response = entities.SearchResponse{
+		ID:          queue.ResponseID,
+		FileName:    queue.FileName,
+		ParseErrors: []entities.ParseError{},
+		Strict:      queue.Strict,
+	}
+
+	reader := queue.Reader
+	if reader == nil {
+		xmlFile, err := os.Open(queue.FileName)
+		if err != nil {
+			logger.Error(ctx, "unable to read file", err)
+			response.ParseErrors = append(response.ParseErrors, entities.ParseError{Message: err.Error()})
+			return response
+		}
+		defer func() {
+			_ = xmlFile.Close()
+		}()
+		if info, statErr := xmlFile.Stat(); statErr == nil {
+			response.FileSize = info.Size()
+			response.FileModifiedAt = info.ModTime()
+		}
+		reader = xmlFile
+	}
+
+	defer func() {
+		queue.Storage.AddResponse(response)
+	}()
+
+	lines := &lineReader{reader: reader}
+	decoder := xml.NewDecoder(lines)
+	addWarning := func(offset int64, warning string) {
+		logger.Warn(ctx, "suspicious itinerary", "line", lines.getLine(offset), "offset", offset, "warning", warning)
+		response.ParseWarnings = append(response.ParseWarnings, entities.ParseError{
+			Line:    lines.getLine(offset),
+			Offset:  offset,
+			Message: warning,
+		})
+	}
+	addError := func(offset int64, err error) {
+		parseError := entities.ParseError{
+			Line:    lines.getLine(offset),
+			Offset:  offset,
+			Message: err.Error(),
+		}
+		if syntaxError, ok := err.(*xml.SyntaxError); ok {
+			parseError.Line = syntaxError.Line
+		}
+		logger.Warn(ctx, "unable to parse response", "line", parseError.Line, "offset", parseError.Offset, "error", parseError.Message)
+		response.ParseErrors = append(response.ParseErrors, parseError)
+	}
+
+	var pending []pendingItinerary
+	flush := func() {
+		w.addItineraries(&response, pending, queue.Storage, addError)
+		pending = pending[:0]
+	}
+	var inElement string
+
+loop:
+	for len(response.ParseErrors) == 0 || !queue.Strict {
+		select {
+		case <-ctx.Done():
+			logger.Info(ctx, "worker canceled", "count", response.ItinerariesCount)
+			addError(decoder.InputOffset(), ctx.Err())
+			w.reject(ctx, &response, queue.Storage)
+			// a canceled file isn't restored as parsed, it's parsed again
+			response.FileSize = 0
+			return response
+		default:
+			t, err := decoder.Token()
+			if err != nil {
+				if err != io.EOF {
+					addError(decoder.InputOffset(), err)
+				}
+				break loop
+			}
+
+			switch se := t.(type) {
+			case xml.StartElement:
+				offset := decoder.InputOffset()
+				inElement = se.Name.Local
+				switch inElement {
+				case "AirFareSearchResponse":
+					err = response.SetAttributes(se.Attr)
+					if err != nil {
+						addError(offset, err)
+					}
+				case "RequestId":
+					err = decoder.DecodeElement(&response.RequestID, &se)
+					if err != nil {
+						addError(offset, err)
+					}
+				case "Flights":
+					var itinerary entities.Itinerary
+					itinerary, err = decodeItinerary(decoder, &se)
+					if _, ok := err.(*xml.SyntaxError); ok {
+						addError(offset, err)
+						break loop
+					}
+					if err == nil {
+						err = itinerary.Validate()
+					}
+					if err != nil {
+						addError(offset, err)
+						continue
+					}
+					itinerary.ResponseID = queue.ResponseID
+					for _, warning := range itinerary.GetWarnings() {
+						addWarning(offset, warning)
+					}
+
+					pending = append(pending, pendingItinerary{itinerary: itinerary, offset: offset})
+					if !queue.Strict && len(pending) >= itinerariesBatchSize {
+						flush()
+					}
+				}
+			default:
+
+			}
+		}
+	}
+
+	if lines.err != nil || queue.Strict && len(response.ParseErrors) > 0 {
+		w.reject(ctx, &response, queue.Storage)
+		return response
+	}
+	flush()
+
+	logger.Info(ctx, "added itineraries", "count", response.ItinerariesCount)
+	return response
+}
+
+// reject removes itineraries stored from the response, batches of a lenient
+// response may be stored before it's rejected.
+func (w *worker) reject(ctx context.Context, response *entities.SearchResponse, store storage.IStorage) {
+	_ = store.RemoveResponse(string(response.ID))
+	response.Rejected = true
+	response.ItinerariesCount = 0
+	response.DuplicatesCount = 0
+	logger.Info(ctx, "response is rejected", "errors", len(response.ParseErrors))
+}
+
+// decodeItinerary reads the whole element before decoding it, so the decoder
+// stays at the next element when the itinerary is malformed.
+func decodeItinerary(decoder *xml.Decoder, start *xml.StartElement) (entities.Itinerary, error) {
+	var itinerary entities.Itinerary
+	var raw struct {
+		Inner []byte `xml:",innerxml"`
+	}
+	if err := decoder.DecodeElement(&raw, start); err != nil {
+		return itinerary, err
+	}
+
+	element := make([]byte, 0, len(raw.Inner)+len("<Flights></Flights>"))
+	element = append(element, "<Flights>"...)
+	element = append(element, raw.Inner...)
+	element = append(element, "</Flights>"...)
+	err := xml.Unmarshal(element, &itinerary)
+	return itinerary, err
+}
+
+type pendingItinerary struct {
+	itinerary entities.Itinerary
+	offset    int64
+}
+
+func (w *worker) addItineraries(
+	response *entities.SearchResponse,
+	pending []pendingItinerary,
+	store storage.IStorage,
+	onError func(offset int64, err error),
+) {
+	if len(pending) == 0 {
+		return
+	}
+
+	itineraries := make([]entities.Itinerary, len(pending))
+	for k := range pending {
+		itineraries[k] = pending[k].itinerary
+	}
+	for k, err := range store.AddItineraries(itineraries) {
+		switch err {
+		case nil:
+			response.ItinerariesCount++
+		case storage.ErrDuplicateItinerary:
+			response.DuplicatesCount++
+		default:
+			onError(pending[k].offset, err)
+		}
+	}
+}
+
+// lineReader remembers offsets of line breaks to find lines of decoder offsets.
+type lineReader struct {
+	reader io.Reader
+	offset int64
+	breaks []int64
+	// err is a read failure, e.g. of a too large body, the rest of the
+	// response is lost after it.
+	err error
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	for i := 0; i < n; i++ {
+		if p[i] == '\n' {
+			r.breaks = append(r.breaks, r.offset+int64(i))
+		}
+	}
+	r.offset += int64(n)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *lineReader) getLine(offset int64) int {
+	return sort.Search(len(r.breaks), func(i int) bool {
+		return r.breaks[i] >= offset
+	}) + 1
+}
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"context"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const responseXML = `<?xml version="1.0" encoding="utf-8"?>
+<AirFareSearchResponse RequestTime="28-09-2015 20:30:19" ResponseTime="28-09-2015 20:30:26">
+	<RequestId>123ABCD</RequestId>
+	<PricedItineraries>
+		<Flights>
+			<OnwardPricedItinerary>
+				<Flights>
+					<Flight>
+						<Carrier id="EK">Emirates</Carrier>
+						<FlightNumber>418</FlightNumber>
+						<Source>DXB</Source>
+						<Destination>BKK</Destination>
+						<DepartureTimeStamp>2018-10-27T0905</DepartureTimeStamp>
+						<ArrivalTimeStamp>2018-10-27T1805</ArrivalTimeStamp>
+						<Class>U</Class>
+						<NumberOfStops>0</NumberOfStops>
+						<TicketType>E</TicketType>
+					</Flight>
+				</Flights>
+			</OnwardPricedItinerary>
+			<Pricing currency="SGD">
+				<ServiceCharges type="SingleAdult" ChargeType="TotalAmount">811.70</ServiceCharges>
+			</Pricing>
+		</Flights>
+	</PricedItineraries>
+</AirFareSearchResponse>`
+
+func TestWorkerPool_Parse(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := SpawnWorkers(1)
+
+	response, err := pool.Parse(&WorkerParserQueue{
+		Ctx:        ctx,
+		ResponseID: "uploaded",
+		Storage:    store,
+		Reader:     strings.NewReader(responseXML),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.ItinerariesCount, "it should parse the itinerary")
+	assert.Equal(t, "123ABCD", response.RequestID)
+
+	stored, err := store.GetResponse("uploaded")
+	assert.NoError(t, err, "it should store the response")
+	assert.Equal(t, 1, stored.ItinerariesCount)
+
+	pool.Flush()
+	_, err = pool.Parse(&WorkerParserQueue{Ctx: ctx, Reader: strings.NewReader(responseXML)})
+	assert.Equal(t, ErrPoolClosed, err, "it should reject responses after flush")
+}
+
+func TestWorkerPool_Parse_Modes(t *testing.T) {
+	valid := strings.SplitAfter(strings.Split(responseXML, "</PricedItineraries>")[0], "<PricedItineraries>")[1]
+	invalid := strings.Replace(valid, "2018-10-27T1805", "27-10-2018", 1)
+	invalid = strings.Replace(invalid, "418", "419", 1)
+	body := "<AirFareSearchResponse>\n<PricedItineraries>" + invalid + valid + "</PricedItineraries>\n</AirFareSearchResponse>"
+
+	items := map[string]struct {
+		strict      bool
+		count       int
+		isRejected  bool
+		errorsCount int
+	}{
+		"it should skip invalid itinerary in lenient mode": {
+			strict:      false,
+			count:       1,
+			errorsCount: 1,
+		},
+		"it should reject response in strict mode": {
+			strict:      true,
+			isRejected:  true,
+			errorsCount: 1,
+		},
+	}
+
+	ctx := context.Background()
+	pool := SpawnWorkers(1)
+	defer pool.Flush()
+	for message, item := range items {
+		store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+		response, err := pool.Parse(&WorkerParserQueue{
+			Ctx:        ctx,
+			ResponseID: "uploaded",
+			Storage:    store,
+			Reader:     strings.NewReader(body),
+			Strict:     item.strict,
+		})
+		assert.NoError(t, err, message)
+		assert.Equal(t, item.count, response.ItinerariesCount, message)
+		assert.Equal(t, item.isRejected, response.Rejected, message)
+		if assert.Len(t, response.ParseErrors, item.errorsCount, message) {
+			assert.Equal(t, 3, response.ParseErrors[0].Line, "it should report line of the itinerary")
+			assert.Contains(t, response.ParseErrors[0].Message, "ArrivalTimeStamp", message)
+		}
+
+		itineraries, _ := store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+		assert.Len(t, itineraries, item.count, message)
+	}
+}
+
+// cancelingReader cancels the context when the reader before it is read.
+type cancelingReader struct {
+	cancel context.CancelFunc
+}
+
+func (r cancelingReader) Read([]byte) (int, error) {
+	r.cancel()
+	return 0, io.EOF
+}
+
+func TestWorkerPool_Parse_Incomplete(t *testing.T) {
+	valid := strings.SplitAfter(strings.Split(responseXML, "</PricedItineraries>")[0], "<PricedItineraries>")[1]
+	var flights strings.Builder
+	for i := 0; i <= itinerariesBatchSize; i++ {
+		flights.WriteString(strings.Replace(valid, "418", strconv.Itoa(1000+i), 1))
+	}
+	body := "<AirFareSearchResponse>\n<PricedItineraries>" + flights.String()
+
+	items := map[string]struct {
+		reader   func(cancel context.CancelFunc) io.Reader
+		expected error
+	}{
+		"it should reject response failed to be read": {
+			reader: func(context.CancelFunc) io.Reader {
+				return io.MultiReader(strings.NewReader(body), iotest.ErrReader(errors.New("response body is too large")))
+			},
+		},
+		"it should reject canceled response": {
+			reader: func(cancel context.CancelFunc) io.Reader {
+				return io.MultiReader(strings.NewReader(body), cancelingReader{cancel: cancel}, strings.NewReader(valid))
+			},
+			expected: context.Canceled,
+		},
+	}
+
+	pool := SpawnWorkers(1)
+	defer pool.Flush()
+	for message, item := range items {
+		ctx, cancel := context.WithCancel(context.Background())
+		store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+		_, err := pool.Parse(&WorkerParserQueue{
+			Ctx:        ctx,
+			ResponseID: "uploaded",
+			Storage:    store,
+			Reader:     item.reader(cancel),
+		})
+		assert.Equal(t, item.expected, err, message)
+
+		itineraries, _ := store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+		assert.Empty(t, itineraries, "it should remove stored batches")
+		stored, err := store.GetResponse("uploaded")
+		if assert.NoError(t, err, message) {
+			assert.True(t, stored.Rejected, message)
+			assert.Zero(t, stored.ItinerariesCount, message)
+			assert.NotEmpty(t, stored.ParseErrors, message)
+		}
+		cancel()
+	}
+}
+
+func TestWorkerPool_Put_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// without workers nothing receives queues
+	pool := SpawnWorkers(0)
+
+	err := pool.Put(&WorkerParserQueue{Ctx: ctx, Reader: strings.NewReader(responseXML)})
+	assert.Equal(t, context.Canceled, err, "it should stop waiting for a worker when context is canceled")
+	pool.Flush()
+}
+
+func TestWorkerPool_Parse_AirportChange(t *testing.T) {
+	flights := strings.SplitAfter(strings.Split(responseXML, "</Flights>\n\t\t\t</OnwardPricedItinerary>")[0], "<Flights>\n")[2]
+	toMaktoum := strings.Replace(flights, "<Destination>BKK</Destination>", "<Destination>DWC</Destination>", 1)
+	fromDubai := strings.Replace(strings.Replace(flights, "2018-10-27T0905", "2018-10-27T2005", 1), "2018-10-27T1805", "2018-10-28T0505", 1)
+	body := strings.Replace(responseXML, flights, toMaktoum+fromDubai, 1)
+
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := SpawnWorkers(1)
+	defer pool.Flush()
+
+	response, err := pool.Parse(&WorkerParserQueue{Ctx: ctx, ResponseID: "uploaded", Storage: store, Reader: strings.NewReader(body)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, response.ItinerariesCount, "it should store itinerary changing airports of the city")
+	assert.Empty(t, response.ParseErrors)
+	if assert.Len(t, response.ParseWarnings, 1) {
+		assert.Contains(t, response.ParseWarnings[0].Message, "airport is changed from DWC")
+	}
+}
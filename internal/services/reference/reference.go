@@ -0,0 +1,132 @@
+package reference
+
+import (
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/airlines"
+	"aviasales/pkg/airports"
+	"aviasales/pkg/entities"
+	"context"
+	"sort"
+	"strings"
+)
+
+// Airport is a bundled airport or a code of loaded itineraries missing in
+// the bundled data, then only Code is set.
+type Airport struct {
+	*airports.Airport
+	// Loaded is set when itineraries of the airport are loaded.
+	Loaded bool
+}
+
+// Airline is a bundled airline or a carrier of loaded itineraries missing
+// in the bundled data, then the partner name is used.
+type Airline struct {
+	*airlines.Airline
+	// Loaded is set when itineraries of the airline are loaded.
+	Loaded bool
+}
+
+type IService interface {
+	// GetAirports returns airports ordered by code, query is resolved like
+	// search source and destination.
+	GetAirports(query string, loadedOnly bool) ([]*Airport, error)
+	// GetAirlines returns airlines ordered by code.
+	GetAirlines(loadedOnly bool) ([]*Airline, error)
+}
+
+type service struct {
+	ctx     context.Context
+	storage storage.IStorage
+}
+
+func New(ctx context.Context, storage storage.IStorage) *service {
+	return &service{
+		ctx:     ctx,
+		storage: storage,
+	}
+}
+
+func (s *service) GetAirports(query string, loadedOnly bool) ([]*Airport, error) {
+	loaded, _, err := s.getLoaded()
+	if err != nil {
+		return nil, err
+	}
+
+	known := airports.GetAll()
+	if query != "" {
+		known = airports.Resolve(query)
+	}
+
+	result := []*Airport{}
+	for _, airport := range known {
+		if loadedOnly && !loaded[airport.Code] {
+			continue
+		}
+		result = append(result, &Airport{Airport: airport, Loaded: loaded[airport.Code]})
+	}
+	for code := range loaded {
+		if _, ok := airports.Get(code); ok {
+			continue
+		}
+		if query != "" && !strings.EqualFold(strings.TrimSpace(query), code) {
+			continue
+		}
+		result = append(result, &Airport{Airport: &airports.Airport{Code: code}, Loaded: true})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+	return result, nil
+}
+
+func (s *service) GetAirlines(loadedOnly bool) ([]*Airline, error) {
+	_, loaded, err := s.getLoaded()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []*Airline{}
+	for _, airline := range airlines.GetAll() {
+		_, ok := loaded[airline.Code]
+		if loadedOnly && !ok {
+			continue
+		}
+		result = append(result, &Airline{Airline: airline, Loaded: ok})
+	}
+	for code, name := range loaded {
+		if _, ok := airlines.Get(code); ok {
+			continue
+		}
+		result = append(result, &Airline{Airline: &airlines.Airline{Code: code, Name: name}, Loaded: true})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+	return result, nil
+}
+
+// getLoaded returns airport codes and carrier names by code of stored
+// itineraries.
+func (s *service) getLoaded() (map[string]bool, map[string]string, error) {
+	itineraries, err := s.storage.GetAllItineraries()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	loadedAirports := map[string]bool{}
+	loadedCarriers := map[string]string{}
+	for _, itinerary := range itineraries {
+		for _, flights := range [][]entities.Flight{itinerary.Onward, itinerary.Return} {
+			for i := range flights {
+				loadedAirports[flights[i].Source] = true
+				loadedAirports[flights[i].Destination] = true
+				if loadedCarriers[flights[i].Carrier.Code] == "" {
+					loadedCarriers[flights[i].Carrier.Code] = strings.TrimSpace(flights[i].Carrier.Name)
+				}
+			}
+		}
+	}
+	return loadedAirports, loadedCarriers, nil
+}
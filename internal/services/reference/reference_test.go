@@ -0,0 +1,104 @@
+package reference
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newService(t *testing.T) *service {
+	ctx := context.Background()
+	store := storage.NewMemoryStorage(
+		ctx,
+		currency.New(ctx, currency.NewStaticProvider(currency.Rates{})),
+		scoring.NewWeighted(scoring.DefaultWeights),
+	)
+	departure := time.Date(2018, 10, 27, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.AddItinerary(entities.Itinerary{
+		ResponseID: "response",
+		Onward: []entities.Flight{
+			{
+				Carrier:            entities.Carrier{Code: "EK", Name: "Emirates"},
+				FlightNumber:       "1",
+				Source:             "DXB",
+				Destination:        "DMK",
+				DepartureTimeStamp: entities.FlightDate{Time: departure},
+				ArrivalTimeStamp:   entities.FlightDate{Time: departure.Add(6 * time.Hour)},
+			},
+			{
+				Carrier:            entities.Carrier{Code: "ZZ", Name: " Partner Air "},
+				FlightNumber:       "2",
+				Source:             "DMK",
+				Destination:        "ZZZ",
+				DepartureTimeStamp: entities.FlightDate{Time: departure.Add(8 * time.Hour)},
+				ArrivalTimeStamp:   entities.FlightDate{Time: departure.Add(9 * time.Hour)},
+			},
+		},
+	}))
+	return New(ctx, store)
+}
+
+func TestService_GetAirports(t *testing.T) {
+	s := newService(t)
+
+	items := map[string]struct {
+		query      string
+		loadedOnly bool
+		expected   []string
+		loaded     []bool
+	}{
+		"it should return loaded airports with unknown ones": {
+			loadedOnly: true,
+			expected:   []string{"DMK", "DXB", "ZZZ"},
+			loaded:     []bool{true, true, true},
+		},
+		"it should resolve city code": {
+			query:    "bkk",
+			expected: []string{"BKK", "DMK"},
+			loaded:   []bool{false, true},
+		},
+		"it should find unknown loaded airport by code": {
+			query:    "zzz",
+			expected: []string{"ZZZ"},
+			loaded:   []bool{true},
+		},
+	}
+
+	for message, item := range items {
+		result, err := s.GetAirports(item.query, item.loadedOnly)
+		assert.NoError(t, err, message)
+		codes, loaded := []string{}, []bool{}
+		for _, airport := range result {
+			codes = append(codes, airport.Code)
+			loaded = append(loaded, airport.Loaded)
+		}
+		assert.Equal(t, item.expected, codes, message)
+		assert.Equal(t, item.loaded, loaded, message)
+	}
+
+	all, err := s.GetAirports("", false)
+	assert.NoError(t, err)
+	assert.True(t, len(all) > 100, "it should return bundled airports")
+}
+
+func TestService_GetAirlines(t *testing.T) {
+	s := newService(t)
+
+	result, err := s.GetAirlines(true)
+	assert.NoError(t, err)
+	if assert.Len(t, result, 2, "it should return loaded airlines") {
+		assert.Equal(t, "Emirates", result[0].Name)
+		assert.Equal(t, "AE", result[0].Country, "it should use bundled data")
+		assert.Equal(t, "Partner Air", result[1].Name, "it should use partner name of unknown airline")
+	}
+
+	all, err := s.GetAirlines(false)
+	assert.NoError(t, err)
+	assert.True(t, len(all) > len(result), "it should return bundled airlines")
+}
@@ -0,0 +1,66 @@
+package scoring
+
+import (
+	"aviasales/pkg/entities"
+	"sort"
+)
+
+type paretoPoint struct {
+	itinerary *entities.Itinerary
+	price     float64
+	duration  int64
+	stops     int
+}
+
+// GetParetoFront returns itineraries not dominated by any other one by
+// party price and total duration and, optionally, number of stops.
+// The result is ordered by price, duration and UUID.
+func GetParetoFront(itineraries []*entities.Itinerary, passengers entities.Passengers, withStops bool) []*entities.Itinerary {
+	passengers = passengers.OrDefault()
+	points := make([]*paretoPoint, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		points = append(points, &paretoPoint{
+			itinerary: itinerary,
+			price:     getPrice(itinerary, passengers),
+			duration:  itinerary.GetDuration(),
+			stops:     itinerary.GetStops(),
+		})
+	}
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if points[i].price != points[j].price {
+			return points[i].price < points[j].price
+		}
+		if points[i].duration != points[j].duration {
+			return points[i].duration < points[j].duration
+		}
+		return points[i].itinerary.UUID < points[j].itinerary.UUID
+	})
+
+	result := []*entities.Itinerary{}
+	for i, point := range points {
+		isDominated := false
+		// only cheaper or equally priced points may dominate
+		for j := 0; j < len(points) && points[j].price <= point.price && !isDominated; j++ {
+			isDominated = i != j && points[j].dominates(point, withStops)
+		}
+		if !isDominated {
+			result = append(result, point.itinerary)
+		}
+	}
+
+	return result
+}
+
+func (p *paretoPoint) dominates(other *paretoPoint, withStops bool) bool {
+	if p.price > other.price || p.duration > other.duration {
+		return false
+	}
+	if withStops && p.stops > other.stops {
+		return false
+	}
+
+	return p.price < other.price ||
+		p.duration < other.duration ||
+		(withStops && p.stops < other.stops)
+}
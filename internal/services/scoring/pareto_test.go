@@ -0,0 +1,37 @@
+package scoring
+
+import (
+	"aviasales/pkg/entities"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetParetoFront(t *testing.T) {
+	dominated := newItinerary("d", 650,
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 9, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 16, 0, 0, 0, time.UTC)),
+	)
+	slowDirect := newItinerary("e", 350,
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 1, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 18, 0, 0, 0, time.UTC)),
+	)
+	itineraries := []*entities.Itinerary{dominated, expensiveDirect, averageDirect, cheapConnecting, slowDirect}
+
+	items := map[string]struct {
+		withStops bool
+		expected  []*entities.Itinerary
+	}{
+		"it should keep price and duration trade-offs": {
+			withStops: false,
+			expected:  []*entities.Itinerary{cheapConnecting, averageDirect, expensiveDirect},
+		},
+		"it should keep fewer stops trade-offs": {
+			withStops: true,
+			expected:  []*entities.Itinerary{cheapConnecting, slowDirect, averageDirect, expensiveDirect},
+		},
+	}
+
+	for message, item := range items {
+		assert.Equal(t, item.expected, GetParetoFront(itineraries, entities.Passengers{}, item.withStops), message)
+	}
+}
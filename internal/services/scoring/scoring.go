@@ -0,0 +1,65 @@
+package scoring
+
+import (
+	"aviasales/pkg/entities"
+	"errors"
+)
+
+const (
+	CriterionPrice         = "price"
+	CriterionFlightTime    = "flightTime"
+	CriterionLayoverTime   = "layoverTime"
+	CriterionStops         = "stops"
+	CriterionDepartureHour = "departureHour"
+)
+
+var ErrInvalidWeights = errors.New("weights should be non-negative with a positive sum")
+
+// DefaultWeights are used when neither config nor request sets weights.
+var DefaultWeights = Weights{
+	Price:         4,
+	FlightTime:    2,
+	LayoverTime:   2,
+	Stops:         1,
+	DepartureHour: 1,
+}
+
+type Weights struct {
+	Price         float64 `yaml:"price"`
+	FlightTime    float64 `yaml:"flightTime"`
+	LayoverTime   float64 `yaml:"layoverTime"`
+	Stops         float64 `yaml:"stops"`
+	DepartureHour float64 `yaml:"departureHour"`
+}
+
+// IStrategy ranks candidates of a city pair for the "optimal" search type.
+type IStrategy interface {
+	Name() string
+	// Rank returns itineraries ordered from the best one, every
+	// itinerary comes with explanation of its score.
+	Rank(itineraries []*entities.Itinerary, passengers entities.Passengers) []*Ranked
+}
+
+type Ranked struct {
+	Itinerary *entities.Itinerary
+	Breakdown *entities.ScoreBreakdown
+}
+
+func (w Weights) Validate() error {
+	values := w.values()
+	var sum float64
+	for _, value := range values {
+		if value < 0 {
+			return ErrInvalidWeights
+		}
+		sum += value
+	}
+	if sum == 0 {
+		return ErrInvalidWeights
+	}
+	return nil
+}
+
+func (w Weights) values() []float64 {
+	return []float64{w.Price, w.FlightTime, w.LayoverTime, w.Stops, w.DepartureHour}
+}
@@ -0,0 +1,148 @@
+package scoring
+
+import (
+	"aviasales/pkg/entities"
+	"sort"
+	"time"
+)
+
+const strategyWeighted = "weighted"
+
+// Departures between comfortableFrom and comfortableTo local time aren't penalized.
+const (
+	comfortableFrom = 7 * time.Hour
+	comfortableTo   = 22 * time.Hour
+)
+
+type criterion struct {
+	name   string
+	weight float64
+	value  func(itinerary *entities.Itinerary, passengers entities.Passengers) float64
+}
+
+// weighted normalizes every criterion over all candidates to [0, 1]
+// and sums them with weights. Lower criteria values are better.
+type weighted struct {
+	criteria []criterion
+}
+
+func NewWeighted(weights Weights) IStrategy {
+	return &weighted{
+		criteria: []criterion{
+			{name: CriterionPrice, weight: weights.Price, value: getPrice},
+			{name: CriterionFlightTime, weight: weights.FlightTime, value: getFlightTime},
+			{name: CriterionLayoverTime, weight: weights.LayoverTime, value: getLayoverTime},
+			{name: CriterionStops, weight: weights.Stops, value: getStops},
+			{name: CriterionDepartureHour, weight: weights.DepartureHour, value: getDepartureHour},
+		},
+	}
+}
+
+func (w *weighted) Name() string {
+	return strategyWeighted
+}
+
+func (w *weighted) Rank(itineraries []*entities.Itinerary, passengers entities.Passengers) []*Ranked {
+	passengers = passengers.OrDefault()
+
+	values := make([][]float64, len(w.criteria))
+	minValues := make([]float64, len(w.criteria))
+	maxValues := make([]float64, len(w.criteria))
+	var weightsSum float64
+	for c := range w.criteria {
+		weightsSum += w.criteria[c].weight
+		values[c] = make([]float64, len(itineraries))
+		for i, itinerary := range itineraries {
+			value := w.criteria[c].value(itinerary, passengers)
+			values[c][i] = value
+			if i == 0 || value < minValues[c] {
+				minValues[c] = value
+			}
+			if i == 0 || value > maxValues[c] {
+				maxValues[c] = value
+			}
+		}
+	}
+
+	result := make([]*Ranked, 0, len(itineraries))
+	for i, itinerary := range itineraries {
+		breakdown := &entities.ScoreBreakdown{
+			Strategy:   w.Name(),
+			Candidates: len(itineraries),
+			Criteria:   make([]entities.CriterionScore, 0, len(w.criteria)),
+		}
+		for c := range w.criteria {
+			normalized := 1.0
+			if maxValues[c] > minValues[c] {
+				normalized = (maxValues[c] - values[c][i]) / (maxValues[c] - minValues[c])
+			}
+			score := 0.0
+			if weightsSum > 0 {
+				score = w.criteria[c].weight * normalized / weightsSum
+			}
+			breakdown.Score += score
+			breakdown.Criteria = append(breakdown.Criteria, entities.CriterionScore{
+				Name:       w.criteria[c].name,
+				Value:      values[c][i],
+				Normalized: normalized,
+				Weight:     w.criteria[c].weight,
+				Score:      score,
+			})
+		}
+		result = append(result, &Ranked{
+			Itinerary: itinerary,
+			Breakdown: breakdown,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Breakdown.Score != result[j].Breakdown.Score {
+			return result[i].Breakdown.Score > result[j].Breakdown.Score
+		}
+		return result[i].Itinerary.UUID < result[j].Itinerary.UUID
+	})
+	for k := range result {
+		result[k].Breakdown.Rank = k + 1
+	}
+
+	return result
+}
+
+func getPrice(itinerary *entities.Itinerary, passengers entities.Passengers) float64 {
+	price, _ := itinerary.GetBasePrice(passengers).Float64()
+	return price
+}
+
+// getFlightTime returns time in the air in minutes.
+func getFlightTime(itinerary *entities.Itinerary, _ entities.Passengers) float64 {
+	return time.Duration(itinerary.GetDurationWithoutTransfer()).Minutes()
+}
+
+// getLayoverTime returns time on the ground in minutes.
+func getLayoverTime(itinerary *entities.Itinerary, _ entities.Passengers) float64 {
+	return time.Duration(itinerary.GetTransferDuration()).Minutes()
+}
+
+func getStops(itinerary *entities.Itinerary, _ entities.Passengers) float64 {
+	return float64(itinerary.GetStops())
+}
+
+// getDepartureHour returns minutes between local departure time and
+// comfortable departure hours of each direction.
+func getDepartureHour(itinerary *entities.Itinerary, _ entities.Passengers) float64 {
+	var result time.Duration
+	for _, flights := range [][]entities.Flight{itinerary.Onward, itinerary.Return} {
+		if len(flights) == 0 {
+			continue
+		}
+		hour, minute, _ := flights[0].DepartureTimeStamp.Clock()
+		clock := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+		switch {
+		case clock < comfortableFrom:
+			result += comfortableFrom - clock
+		case clock > comfortableTo:
+			result += clock - comfortableTo
+		}
+	}
+	return result.Minutes()
+}
@@ -0,0 +1,96 @@
+package scoring
+
+import (
+	"aviasales/pkg/entities"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newItinerary(uuid string, price float64, flights ...entities.Flight) *entities.Itinerary {
+	return &entities.Itinerary{
+		UUID:   entities.ItineraryUUID(uuid),
+		Onward: flights,
+		Pricing: &entities.Price{
+			Currency: "SGD",
+			ServiceCharges: []entities.Charge{
+				{
+					ChargeType: entities.ChargeTypeTotalAmount,
+					Type:       entities.TypeSingleAdult,
+					Cost:       decimal.NewFromFloat(price),
+				},
+			},
+		},
+	}
+}
+
+func newFlight(source, destination string, departure, arrival time.Time) entities.Flight {
+	return entities.Flight{
+		Source:             source,
+		Destination:        destination,
+		DepartureTimeStamp: entities.FlightDate{Time: departure},
+		ArrivalTimeStamp:   entities.FlightDate{Time: arrival},
+		NumberOfStops:      "0",
+	}
+}
+
+var (
+	cheapConnecting = newItinerary("a", 300,
+		newFlight("DXB", "DEL", time.Date(2018, 10, 22, 2, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 5, 0, 0, 0, time.UTC)),
+		newFlight("DEL", "BKK", time.Date(2018, 10, 22, 14, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 18, 0, 0, 0, time.UTC)),
+	)
+	expensiveDirect = newItinerary("b", 600,
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 9, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 15, 0, 0, 0, time.UTC)),
+	)
+	averageDirect = newItinerary("c", 450,
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 23, 0, 0, 0, time.UTC), time.Date(2018, 10, 23, 5, 30, 0, 0, time.UTC)),
+	)
+)
+
+func TestWeighted_Rank(t *testing.T) {
+	items := map[string]struct {
+		weights      Weights
+		expectedBest *entities.Itinerary
+	}{
+		"it should prefer the cheapest one by price": {
+			weights:      Weights{Price: 1},
+			expectedBest: cheapConnecting,
+		},
+		"it should prefer the direct one by stops and flight time": {
+			weights:      Weights{FlightTime: 1, Stops: 1},
+			expectedBest: expensiveDirect,
+		},
+		"it should prefer the average one by default": {
+			weights:      DefaultWeights,
+			expectedBest: averageDirect,
+		},
+	}
+
+	passengers := entities.Passengers{}
+	for message, item := range items {
+		strategy := NewWeighted(item.weights)
+		ranked := strategy.Rank([]*entities.Itinerary{cheapConnecting, expensiveDirect, averageDirect}, passengers)
+		reversed := strategy.Rank([]*entities.Itinerary{averageDirect, expensiveDirect, cheapConnecting}, passengers)
+
+		assert.Len(t, ranked, 3, message)
+		assert.Equal(t, item.expectedBest, ranked[0].Itinerary, message)
+		for k := range ranked {
+			assert.Equal(t, ranked[k].Itinerary, reversed[k].Itinerary, "it shouldn't depend on order: "+message)
+			assert.Equal(t, k+1, ranked[k].Breakdown.Rank, message)
+
+			var sum float64
+			for _, criterion := range ranked[k].Breakdown.Criteria {
+				sum += criterion.Score
+			}
+			assert.InDelta(t, ranked[k].Breakdown.Score, sum, 1e-9, message)
+		}
+	}
+}
+
+func TestWeights_Validate(t *testing.T) {
+	assert.NoError(t, DefaultWeights.Validate())
+	assert.Equal(t, ErrInvalidWeights, Weights{}.Validate())
+	assert.Equal(t, ErrInvalidWeights, Weights{Price: 1, Stops: -1}.Validate())
+}
@@ -1,36 +1,152 @@
 package services
 
 import (
+	"aviasales/internal/config"
+	"aviasales/internal/services/compare"
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/graph"
+	"aviasales/internal/services/parser"
+	"aviasales/internal/services/reference"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/stats"
 	"aviasales/internal/services/storage"
+	"aviasales/internal/services/watcher"
+	"aviasales/pkg/logger"
 	"context"
 	"sync"
+	"time"
 )
 
 type factory struct {
-	ctx      context.Context
-	safeInit servicesInitLocks
-	storage  storage.IStorage
+	ctx       context.Context
+	config    *config.Config
+	safeInit  servicesInitLocks
+	storage   storage.IStorage
+	compare   compare.IService
+	scoring   scoring.IStrategy
+	currency  currency.IService
+	parser    parser.IPool
+	watcher   watcher.IService
+	graph     graph.IService
+	reference reference.IService
+	stats     stats.IService
 }
 
 type servicesInitLocks struct {
-	storage sync.Once
+	storage   sync.Once
+	compare   sync.Once
+	scoring   sync.Once
+	currency  sync.Once
+	parser    sync.Once
+	watcher   sync.Once
+	graph     sync.Once
+	reference sync.Once
+	stats     sync.Once
 }
 
 type IServiceFactory interface {
+	Config() *config.Config
 	Storage() storage.IStorage
+	Compare() compare.IService
+	Scoring() scoring.IStrategy
+	Currency() currency.IService
+	Parser() parser.IPool
+	Watcher() watcher.IService
+	Graph() graph.IService
+	Reference() reference.IService
+	Stats() stats.IService
 }
 
 func NewServiceFactory(
 	ctx context.Context,
+	config *config.Config,
 ) IServiceFactory {
 	return &factory{
-		ctx: ctx,
+		ctx:    ctx,
+		config: config,
 	}
 }
 
+func (f *factory) Config() *config.Config {
+	return f.config
+}
+
 func (f *factory) Storage() storage.IStorage {
 	f.safeInit.storage.Do(func() {
-		f.storage = storage.New(f.ctx)
+		var err error
+		f.storage, err = storage.New(
+			f.ctx,
+			f.config.Storage.Backend,
+			f.config.Storage.FileName,
+			f.Currency(),
+			f.Scoring(),
+		)
+		if err != nil {
+			logger.FatalE(f.ctx, "unable to open storage", err, "backend", f.config.Storage.Backend)
+		}
 	})
 	return f.storage
 }
+
+func (f *factory) Compare() compare.IService {
+	f.safeInit.compare.Do(func() {
+		f.compare = compare.New(f.ctx, f.Storage(), f.Currency())
+	})
+	return f.compare
+}
+
+func (f *factory) Scoring() scoring.IStrategy {
+	f.safeInit.scoring.Do(func() {
+		f.scoring = scoring.NewWeighted(f.config.Scoring)
+	})
+	return f.scoring
+}
+
+func (f *factory) Currency() currency.IService {
+	f.safeInit.currency.Do(func() {
+		f.currency = currency.New(f.ctx, currency.NewFileProvider(f.config.Currency.RatesFile))
+	})
+	return f.currency
+}
+
+func (f *factory) Parser() parser.IPool {
+	f.safeInit.parser.Do(func() {
+		f.parser = parser.SpawnWorkers(f.config.Parser.Workers)
+	})
+	return f.parser
+}
+
+func (f *factory) Watcher() watcher.IService {
+	f.safeInit.watcher.Do(func() {
+		f.watcher = watcher.New(
+			f.ctx,
+			f.config.Watch.Directories,
+			time.Duration(f.config.Watch.Interval),
+			f.config.Parser.Strict,
+			f.Storage(),
+			f.Parser(),
+		)
+	})
+	return f.watcher
+}
+
+func (f *factory) Graph() graph.IService {
+	f.safeInit.graph.Do(func() {
+		f.graph = graph.New(f.ctx, f.Storage(), f.config.GetGraphRules())
+	})
+	return f.graph
+}
+
+func (f *factory) Reference() reference.IService {
+	f.safeInit.reference.Do(func() {
+		f.reference = reference.New(f.ctx, f.Storage())
+	})
+	return f.reference
+}
+
+func (f *factory) Stats() stats.IService {
+	f.safeInit.stats.Do(func() {
+		f.stats = stats.New(f.ctx, f.Storage(), f.Currency())
+	})
+	return f.stats
+}
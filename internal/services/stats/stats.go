@@ -0,0 +1,373 @@
+package stats
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// durationBucket is a width of the duration histogram.
+const durationBucket = time.Hour
+
+var (
+	ErrInvalidPercentile = errors.New("percentile should be from 1 to 99")
+	// DefaultPercentiles are reported when Options don't set them.
+	DefaultPercentiles = []int{10, 25, 75, 90}
+)
+
+type Options struct {
+	Percentiles []int
+	// ByResponse adds stats of every source response.
+	ByResponse bool
+}
+
+// PairStats describes stored itineraries of a city pair, source and
+// destination list the airports comma separated. Prices are in the base
+// currency, or as priced by partners when no rates are loaded.
+type PairStats struct {
+	Source      string              `json:"source"`
+	Destination string              `json:"destination"`
+	ResponseID  entities.ResponseID `json:"responseId,omitempty"`
+	Count       int                 `json:"count"`
+	// FlightCombinations counts itineraries with distinct flights
+	// (see entities.Itinerary.GetFlightsKey).
+	FlightCombinations int             `json:"flightCombinations"`
+	Currency           string          `json:"currency"`
+	Prices             []*PriceStats   `json:"prices"`
+	Duration           *DurationStats  `json:"duration"`
+	Stops              []*StopsCount   `json:"stops"`
+	Carriers           []*CarrierShare `json:"carriers"`
+	Responses          []*PairStats    `json:"responses,omitempty"`
+}
+
+// PriceStats describes TotalAmount of one passenger type over itineraries
+// having the fare, itineraries without base rate aren't counted.
+type PriceStats struct {
+	Type        string             `json:"type"`
+	Count       int                `json:"count"`
+	Min         decimal.Decimal    `json:"min"`
+	Max         decimal.Decimal    `json:"max"`
+	Mean        decimal.Decimal    `json:"mean"`
+	Median      decimal.Decimal    `json:"median"`
+	Percentiles []*PricePercentile `json:"percentiles"`
+}
+
+type PricePercentile struct {
+	Percentile int             `json:"percentile"`
+	Value      decimal.Decimal `json:"value"`
+}
+
+// DurationStats describes travel time of both directions, in nanoseconds.
+type DurationStats struct {
+	Min         time.Duration         `json:"min"`
+	Max         time.Duration         `json:"max"`
+	Mean        time.Duration         `json:"mean"`
+	Median      time.Duration         `json:"median"`
+	Percentiles []*DurationPercentile `json:"percentiles"`
+	// Histogram has hourly buckets with itineraries only.
+	Histogram []*DurationBucket `json:"histogram"`
+}
+
+type DurationPercentile struct {
+	Percentile int           `json:"percentile"`
+	Value      time.Duration `json:"value"`
+}
+
+type DurationBucket struct {
+	From  time.Duration `json:"from"`
+	To    time.Duration `json:"to"`
+	Count int           `json:"count"`
+}
+
+// StopsCount is a number of itineraries with stops of both directions.
+type StopsCount struct {
+	Stops int     `json:"stops"`
+	Count int     `json:"count"`
+	Share float64 `json:"share"`
+}
+
+// CarrierShare is a share of flights operated by the carrier, Itineraries
+// counts itineraries with any of its flights.
+type CarrierShare struct {
+	Code        string  `json:"code"`
+	Flights     int     `json:"flights"`
+	Itineraries int     `json:"itineraries"`
+	Share       float64 `json:"share"`
+}
+
+type IService interface {
+	// GetPairStats describes itineraries of every pair of the source and
+	// destination airports together.
+	GetPairStats(sources, destinations []string, options Options) (*PairStats, error)
+}
+
+type service struct {
+	ctx      context.Context
+	storage  storage.IStorage
+	currency currency.IService
+}
+
+func New(ctx context.Context, storage storage.IStorage, currency currency.IService) *service {
+	return &service{
+		ctx:      ctx,
+		storage:  storage,
+		currency: currency,
+	}
+}
+
+func (s *service) GetPairStats(sources, destinations []string, options Options) (*PairStats, error) {
+	percentiles := options.Percentiles
+	if len(percentiles) == 0 {
+		percentiles = DefaultPercentiles
+	}
+	for _, percentile := range percentiles {
+		if percentile < 1 || percentile > 99 {
+			return nil, ErrInvalidPercentile
+		}
+	}
+
+	itineraries, err := s.storage.GetItineraries(sources, destinations, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := s.getStats(itineraries, percentiles)
+	source, destination := strings.Join(sources, ","), strings.Join(destinations, ",")
+	result.Source, result.Destination = source, destination
+	if !options.ByResponse {
+		return result, nil
+	}
+
+	result.Responses, err = s.getResponsesStats(sources, destinations, percentiles)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getResponsesStats describes offers of the pair as loaded from every
+// response, city pair keeps an offer of several responses once.
+func (s *service) getResponsesStats(sources, destinations []string, percentiles []int) ([]*PairStats, error) {
+	responses, err := s.storage.GetResponses()
+	if err != nil {
+		return nil, err
+	}
+
+	sourcesSet, destinationsSet := toSet(sources), toSet(destinations)
+	source, destination := strings.Join(sources, ","), strings.Join(destinations, ",")
+	result := []*PairStats{}
+	for _, response := range responses {
+		itineraries, responseErr := s.storage.GetResponseItineraries(string(response.ID))
+		if responseErr != nil {
+			// the response has no itineraries
+			continue
+		}
+		matched := []*entities.Itinerary{}
+		for _, itinerary := range itineraries.Itineraries {
+			onward := itinerary.Onward
+			if sourcesSet[onward[0].Source] && destinationsSet[onward[len(onward)-1].Destination] {
+				matched = append(matched, itinerary)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		stats := s.getStats(matched, percentiles)
+		stats.Source, stats.Destination, stats.ResponseID = source, destination, response.ID
+		result = append(result, stats)
+	}
+	return result, nil
+}
+
+func toSet(codes []string) map[string]bool {
+	result := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		result[code] = true
+	}
+	return result
+}
+
+func (s *service) getStats(itineraries []*entities.Itinerary, percentiles []int) *PairStats {
+	flightsKeys := map[string]bool{}
+	for _, itinerary := range itineraries {
+		flightsKeys[itinerary.GetFlightsKey()] = true
+	}
+
+	return &PairStats{
+		Count:              len(itineraries),
+		FlightCombinations: len(flightsKeys),
+		Currency:           s.currency.GetBase(),
+		Prices:             getPriceStats(itineraries, percentiles),
+		Duration:           getDurationStats(itineraries, percentiles),
+		Stops:              getStopsCounts(itineraries),
+		Carriers:           getCarrierShares(itineraries),
+	}
+}
+
+func getPriceStats(itineraries []*entities.Itinerary, percentiles []int) []*PriceStats {
+	result := []*PriceStats{}
+	for _, rateType := range []string{entities.TypeSingleAdult, entities.TypeSingleChild, entities.TypeSingleInfant} {
+		prices := []decimal.Decimal{}
+		for _, itinerary := range itineraries {
+			if !itinerary.HasFare(rateType) || itinerary.NoBaseRate {
+				continue
+			}
+			price := itinerary.GetPrice(entities.ChargeTypeTotalAmount, rateType)
+			if !itinerary.BaseRate.IsZero() {
+				price = price.Mul(itinerary.BaseRate)
+			}
+			prices = append(prices, price)
+		}
+		if len(prices) == 0 {
+			continue
+		}
+		sort.Slice(prices, func(i, j int) bool {
+			return prices[i].LessThan(prices[j])
+		})
+
+		sum := decimal.Zero
+		for _, price := range prices {
+			sum = sum.Add(price)
+		}
+		count := decimal.NewFromInt(int64(len(prices)))
+		stats := &PriceStats{
+			Type:        rateType,
+			Count:       len(prices),
+			Min:         prices[0],
+			Max:         prices[len(prices)-1],
+			Mean:        sum.Div(count).Round(2),
+			Median:      getMedianPrice(prices),
+			Percentiles: []*PricePercentile{},
+		}
+		for _, percentile := range percentiles {
+			stats.Percentiles = append(stats.Percentiles, &PricePercentile{
+				Percentile: percentile,
+				Value:      prices[getRank(len(prices), percentile)],
+			})
+		}
+		result = append(result, stats)
+	}
+	return result
+}
+
+func getDurationStats(itineraries []*entities.Itinerary, percentiles []int) *DurationStats {
+	result := &DurationStats{
+		Percentiles: []*DurationPercentile{},
+		Histogram:   []*DurationBucket{},
+	}
+	if len(itineraries) == 0 {
+		return result
+	}
+
+	durations := make([]time.Duration, 0, len(itineraries))
+	var sum time.Duration
+	for _, itinerary := range itineraries {
+		duration := time.Duration(itinerary.GetDuration())
+		durations = append(durations, duration)
+		sum += duration
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	result.Min = durations[0]
+	result.Max = durations[len(durations)-1]
+	result.Mean = sum / time.Duration(len(durations))
+	result.Median = durations[len(durations)/2]
+	if len(durations)%2 == 0 {
+		result.Median = (durations[len(durations)/2-1] + durations[len(durations)/2]) / 2
+	}
+	for _, percentile := range percentiles {
+		result.Percentiles = append(result.Percentiles, &DurationPercentile{
+			Percentile: percentile,
+			Value:      durations[getRank(len(durations), percentile)],
+		})
+	}
+	for _, duration := range durations {
+		from := duration.Truncate(durationBucket)
+		last := len(result.Histogram) - 1
+		if last < 0 || result.Histogram[last].From != from {
+			result.Histogram = append(result.Histogram, &DurationBucket{From: from, To: from + durationBucket})
+			last++
+		}
+		result.Histogram[last].Count++
+	}
+	return result
+}
+
+func getStopsCounts(itineraries []*entities.Itinerary) []*StopsCount {
+	counts := map[int]int{}
+	for _, itinerary := range itineraries {
+		counts[itinerary.GetStops()]++
+	}
+
+	result := make([]*StopsCount, 0, len(counts))
+	for stops, count := range counts {
+		result = append(result, &StopsCount{
+			Stops: stops,
+			Count: count,
+			Share: float64(count) / float64(len(itineraries)),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Stops < result[j].Stops
+	})
+	return result
+}
+
+func getCarrierShares(itineraries []*entities.Itinerary) []*CarrierShare {
+	shares := map[string]*CarrierShare{}
+	total := 0
+	for _, itinerary := range itineraries {
+		for _, code := range itinerary.GetCarriers() {
+			if _, ok := shares[code]; !ok {
+				shares[code] = &CarrierShare{Code: code}
+			}
+			shares[code].Itineraries++
+		}
+		for _, flights := range [][]entities.Flight{itinerary.Onward, itinerary.Return} {
+			for i := range flights {
+				shares[flights[i].Carrier.Code].Flights++
+				total++
+			}
+		}
+	}
+
+	result := make([]*CarrierShare, 0, len(shares))
+	for _, share := range shares {
+		share.Share = float64(share.Flights) / float64(total)
+		result = append(result, share)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Flights != result[j].Flights {
+			return result[i].Flights > result[j].Flights
+		}
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+func getMedianPrice(prices []decimal.Decimal) decimal.Decimal {
+	middle := len(prices) / 2
+	if len(prices)%2 == 1 {
+		return prices[middle]
+	}
+	return prices[middle-1].Add(prices[middle]).Div(decimal.NewFromInt(2)).Round(2)
+}
+
+// getRank returns index of the percentile in sorted values by the
+// nearest-rank method.
+func getRank(count, percentile int) int {
+	rank := (percentile*count + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return rank - 1
+}
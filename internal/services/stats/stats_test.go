@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func newItinerary(responseID, number string, hours []int, carriers []string, adult float64) entities.Itinerary {
+	departure := time.Date(2018, 10, 27, 0, 0, 0, 0, time.UTC)
+	itinerary := entities.Itinerary{
+		ResponseID: entities.ResponseID(responseID),
+		Pricing: &entities.Price{
+			Currency: "SGD",
+			ServiceCharges: []entities.Charge{
+				{ChargeType: entities.ChargeTypeTotalAmount, Type: entities.TypeSingleAdult, Cost: decimal.NewFromFloat(adult)},
+			},
+		},
+	}
+	airports := []string{"DXB", "DEL", "BKK"}
+	if len(hours) == 1 {
+		airports = []string{"DXB", "BKK"}
+	}
+	for i, duration := range hours {
+		itinerary.Onward = append(itinerary.Onward, entities.Flight{
+			Carrier:            entities.Carrier{Code: carriers[i]},
+			FlightNumber:       number,
+			FareBasis:          responseID,
+			Source:             airports[i],
+			Destination:        airports[i+1],
+			DepartureTimeStamp: entities.FlightDate{Time: departure},
+			ArrivalTimeStamp:   entities.FlightDate{Time: departure.Add(time.Duration(duration) * time.Hour)},
+		})
+		departure = departure.Add(time.Duration(duration+1) * time.Hour)
+	}
+	return itinerary
+}
+
+func TestService_GetPairStats(t *testing.T) {
+	ctx := context.Background()
+	rates := currency.New(ctx, currency.NewStaticProvider(currency.Rates{}))
+	store := storage.NewMemoryStorage(ctx, rates, scoring.NewWeighted(scoring.DefaultWeights))
+	for _, itinerary := range []entities.Itinerary{
+		newItinerary("response1", "1", []int{6}, []string{"EK"}, 400),
+		newItinerary("response1", "2", []int{3, 5}, []string{"AI", "AI"}, 200),
+		newItinerary("response2", "2", []int{3, 5}, []string{"AI", "AI"}, 250),
+		newItinerary("response2", "3", []int{3, 5}, []string{"EK", "TG"}, 300),
+	} {
+		assert.NoError(t, store.AddItinerary(itinerary))
+	}
+	store.AddResponse(entities.SearchResponse{ID: "response1"})
+	store.AddResponse(entities.SearchResponse{ID: "response2"})
+	s := New(ctx, store, rates)
+
+	result, err := s.GetPairStats([]string{"DXB"}, []string{"BKK"}, Options{Percentiles: []int{50, 90}, ByResponse: true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, result.Count, "it should count all itineraries")
+	assert.Equal(t, 3, result.FlightCombinations, "it should count the same flights once")
+	if assert.Len(t, result.Prices, 1, "it should skip passenger types without fares") {
+		prices := result.Prices[0]
+		assert.Equal(t, "200", prices.Min.String())
+		assert.Equal(t, "400", prices.Max.String())
+		assert.Equal(t, "287.5", prices.Mean.String())
+		assert.Equal(t, "275", prices.Median.String())
+		assert.Equal(t, "250", prices.Percentiles[0].Value.String(), "it should use the nearest rank")
+		assert.Equal(t, "400", prices.Percentiles[1].Value.String())
+	}
+	assert.Equal(t, 6*time.Hour, result.Duration.Min)
+	assert.Equal(t, 9*time.Hour, result.Duration.Max)
+	assert.Equal(t, []*DurationBucket{
+		{From: 6 * time.Hour, To: 7 * time.Hour, Count: 1},
+		{From: 9 * time.Hour, To: 10 * time.Hour, Count: 3},
+	}, result.Duration.Histogram)
+	assert.Equal(t, []*StopsCount{
+		{Stops: 0, Count: 1, Share: 0.25},
+		{Stops: 1, Count: 3, Share: 0.75},
+	}, result.Stops)
+	assert.Equal(t, []*CarrierShare{
+		{Code: "AI", Flights: 4, Itineraries: 2, Share: 4.0 / 7},
+		{Code: "EK", Flights: 2, Itineraries: 2, Share: 2.0 / 7},
+		{Code: "TG", Flights: 1, Itineraries: 1, Share: 1.0 / 7},
+	}, result.Carriers)
+	if assert.Len(t, result.Responses, 2, "it should add stats of every response") {
+		assert.Equal(t, entities.ResponseID("response1"), result.Responses[0].ResponseID)
+		assert.Equal(t, 2, result.Responses[0].Count)
+		assert.Nil(t, result.Responses[0].Responses)
+	}
+
+	empty, err := s.GetPairStats([]string{"DXB"}, []string{"ZZZ"}, Options{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, empty.Count, "it should describe unknown pair as empty")
+	assert.Len(t, empty.Duration.Percentiles, 0)
+
+	_, err = s.GetPairStats([]string{"DXB"}, []string{"BKK"}, Options{Percentiles: []int{100}})
+	assert.Equal(t, ErrInvalidPercentile, err)
+}
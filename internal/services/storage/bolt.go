@@ -0,0 +1,285 @@
+package storage
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"aviasales/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const boltOpenTimeout = time.Second
+
+var (
+	// itinerariesBucket keeps itineraries by sequence, so they are loaded
+	// in the order they were added.
+	itinerariesBucket = []byte("itineraries")
+	// responseItinerariesBucket keeps sequences of itineraries in a nested
+	// bucket of every response.
+	responseItinerariesBucket = []byte("responseItineraries")
+	responsesBucket           = []byte("responses")
+)
+
+// boltStorage keeps itineraries and responses in a bbolt file. Indexes
+// stay in memory, they are built again from the file on open, so rankings
+// are the same as of the memory storage.
+type boltStorage struct {
+	*service
+	db *bbolt.DB
+	// isWriting keeps the file and the memory indexes in the same order.
+	isWriting sync.Mutex
+}
+
+func NewBoltStorage(
+	ctx context.Context,
+	fileName string,
+	currency currency.IService,
+	strategy scoring.IStrategy,
+) (*boltStorage, error) {
+	db, err := bbolt.Open(fileName, 0600, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &boltStorage{
+		service: NewMemoryStorage(ctx, currency, strategy),
+		db:      db,
+	}
+	if err = s.load(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load builds memory indexes from the file, itineraries which can't be
+// indexed anymore, e.g. priced in a currency without rate, are skipped.
+func (s *boltStorage) load() error {
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{itinerariesBucket, responseItinerariesBucket, responsesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.db.View(func(tx *bbolt.Tx) error {
+		err := tx.Bucket(responsesBucket).ForEach(func(_, value []byte) error {
+			var response entities.SearchResponse
+			if err := decode(value, &response); err != nil {
+				return err
+			}
+			// gob doesn't keep empty slices
+			if response.ParseErrors == nil {
+				response.ParseErrors = []entities.ParseError{}
+			}
+			s.service.AddResponse(response)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.loadItineraries(tx)
+	})
+}
+
+func (s *boltStorage) loadItineraries(tx *bbolt.Tx) error {
+	count := 0
+	err := tx.Bucket(itinerariesBucket).ForEach(func(_, value []byte) error {
+		var itinerary entities.Itinerary
+		if err := decode(value, &itinerary); err != nil {
+			return err
+		}
+		if err := s.service.AddItinerary(itinerary); err != nil {
+			logger.Warn(s.ctx, "unable to load itinerary", "responseID", itinerary.ResponseID, "error", err.Error())
+			return nil
+		}
+		count++
+		return nil
+	})
+	logger.Info(s.ctx, "loaded itineraries", "count", count)
+	return err
+}
+
+func (s *boltStorage) AddItinerary(itinerary entities.Itinerary) error {
+	return s.AddItineraries([]entities.Itinerary{itinerary})[0]
+}
+
+// AddItineraries writes the batch in one transaction before indexing it,
+// so indexes never have itineraries missing in the file.
+func (s *boltStorage) AddItineraries(itineraries []entities.Itinerary) []error {
+	s.isWriting.Lock()
+	defer s.isWriting.Unlock()
+
+	errs := s.checkItineraries(itineraries)
+	stored := make([]entities.Itinerary, 0, len(itineraries))
+	for k := range itineraries {
+		if errs[k] == nil {
+			stored = append(stored, itineraries[k])
+		}
+	}
+	if len(stored) == 0 {
+		return errs
+	}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		for k := range stored {
+			if err := putItinerary(tx, stored[k]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for k := range errs {
+			if errs[k] == nil {
+				errs[k] = err
+			}
+		}
+		return errs
+	}
+
+	s.service.AddItineraries(stored)
+	return errs
+}
+
+// checkItineraries finds itineraries which memory storage would reject,
+// including duplicates within the batch.
+func (s *boltStorage) checkItineraries(itineraries []entities.Itinerary) []error {
+	s.service.isUpdating.RLock()
+	defer s.service.isUpdating.RUnlock()
+
+	errs := make([]error, len(itineraries))
+	batch := map[entities.ResponseID]map[entities.ItineraryUUID]struct{}{}
+	for k := range itineraries {
+		if errs[k] = s.service.checkItinerary(&itineraries[k]); errs[k] != nil {
+			continue
+		}
+		responseID := itineraries[k].ResponseID
+		if _, ok := batch[responseID]; !ok {
+			batch[responseID] = map[entities.ItineraryUUID]struct{}{}
+		}
+		itineraryUUID := itineraries[k].GetContentUUID()
+		if _, ok := batch[responseID][itineraryUUID]; ok {
+			errs[k] = ErrDuplicateItinerary
+			continue
+		}
+		batch[responseID][itineraryUUID] = struct{}{}
+	}
+	return errs
+}
+
+func putItinerary(tx *bbolt.Tx, itinerary entities.Itinerary) error {
+	value, err := encode(itinerary)
+	if err != nil {
+		return err
+	}
+
+	itineraries := tx.Bucket(itinerariesBucket)
+	sequence, err := itineraries.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, sequence)
+	if err = itineraries.Put(key, value); err != nil {
+		return err
+	}
+	if itinerary.ResponseID == "" {
+		return nil
+	}
+
+	response, err := tx.Bucket(responseItinerariesBucket).CreateBucketIfNotExists([]byte(itinerary.ResponseID))
+	if err != nil {
+		return err
+	}
+	return response.Put(key, nil)
+}
+
+// AddResponse writes the response before keeping it in memory, a response
+// failed to be written isn't listed.
+func (s *boltStorage) AddResponse(response entities.SearchResponse) {
+	s.isWriting.Lock()
+	defer s.isWriting.Unlock()
+
+	value, err := encode(response)
+	if err == nil {
+		err = s.db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket(responsesBucket).Put([]byte(response.ID), value)
+		})
+	}
+	if err != nil {
+		logger.Error(s.ctx, "unable to store response", err, "responseID", response.ID)
+		return
+	}
+
+	s.service.AddResponse(response)
+}
+
+// RemoveResponse deletes the response from the file before memory indexes,
+// so a failed deletion leaves both of them unchanged.
+func (s *boltStorage) RemoveResponse(responseID string) error {
+	s.isWriting.Lock()
+	defer s.isWriting.Unlock()
+
+	if !s.service.hasResponse(responseID) {
+		return errors.New("unable to find response")
+	}
+
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		if err := tx.Bucket(responsesBucket).Delete([]byte(responseID)); err != nil {
+			return err
+		}
+
+		responses := tx.Bucket(responseItinerariesBucket)
+		response := responses.Bucket([]byte(responseID))
+		if response == nil {
+			return nil
+		}
+		itineraries := tx.Bucket(itinerariesBucket)
+		err := response.ForEach(func(key, _ []byte) error {
+			return itineraries.Delete(key)
+		})
+		if err != nil {
+			return err
+		}
+		return responses.DeleteBucket([]byte(responseID))
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.service.RemoveResponse(responseID)
+}
+
+func (s *boltStorage) Close() error {
+	s.isWriting.Lock()
+	defer s.isWriting.Unlock()
+
+	return s.db.Close()
+}
+
+func encode(value interface{}) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func decode(data []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(value)
+}
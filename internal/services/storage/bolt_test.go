@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBoltStorage_Reopen(t *testing.T) {
+	directory, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	fileName := filepath.Join(directory, "avia.db")
+	open := func() *boltStorage {
+		storage, openErr := NewBoltStorage(context.Background(), fileName, newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+		assert.NoError(t, openErr)
+		return storage
+	}
+
+	storage := open()
+	for i, responseID := range []entities.ResponseID{"response1", "response2"} {
+		itinerary := itineraries[i]
+		itinerary.ResponseID = responseID
+		assert.NoError(t, storage.AddItinerary(itinerary))
+		storage.AddResponse(entities.SearchResponse{ID: responseID, ItinerariesCount: 1, ParseErrors: []entities.ParseError{}})
+	}
+	assert.NoError(t, storage.RemoveResponse("response2"))
+	assert.NoError(t, storage.Close())
+
+	storage = open()
+	defer storage.Close()
+	responses, _ := storage.GetResponses()
+	expected := []*entities.SearchResponse{{ID: "response1", ItinerariesCount: 1, ParseErrors: []entities.ParseError{}}}
+	assert.Equal(t, expected, responses, "it should keep responses after restart")
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	if assert.Len(t, all, 1, "it should keep itineraries after restart") {
+		assert.Equal(t, itineraries[0].GetContentUUID(), all[0].UUID)
+		assert.Equal(t, entities.ResponseID("response1"), all[0].ResponseID)
+	}
+	cheapest, _ := storage.GetCheapest(sources, destinations, nil)
+	assert.Equal(t, all[0], cheapest, "it should build indexes after restart")
+}
+
+func TestBoltStorage_WriteFailure(t *testing.T) {
+	directory, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	storage, err := NewBoltStorage(context.Background(), filepath.Join(directory, "avia.db"), newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+	assert.NoError(t, err)
+	itinerary := itineraries[0]
+	itinerary.ResponseID = "response1"
+	assert.NoError(t, storage.AddItinerary(itinerary))
+	storage.AddResponse(entities.SearchResponse{ID: "response1"})
+
+	// writes fail after the file is closed
+	assert.NoError(t, storage.Close())
+	storage.AddResponse(entities.SearchResponse{ID: "response2"})
+	_, err = storage.GetResponse("response2")
+	assert.Error(t, err, "it should not list response failed to be written")
+
+	assert.Error(t, storage.RemoveResponse("response1"))
+	_, err = storage.GetResponse("response1")
+	assert.NoError(t, err, "it should keep response failed to be deleted")
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	assert.Len(t, all, 1, "it should keep itineraries of response failed to be deleted")
+}
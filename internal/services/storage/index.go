@@ -0,0 +1,237 @@
+package storage
+
+import (
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	RankingCheapest      = "cheapest"
+	RankingMostExpensive = "mostExpensive"
+	RankingLongest       = "longest"
+	RankingShortest      = "shortest"
+	RankingOptimal       = "optimal"
+)
+
+var ErrUnknownRanking = errors.New("unknown ranking")
+
+type indexEntry struct {
+	key       decimal.Decimal
+	itinerary *entities.Itinerary
+}
+
+// orderedIndex keeps itineraries sorted by key ascending, itineraries
+// with equal keys are sorted by UUID.
+type orderedIndex struct {
+	entries []indexEntry
+}
+
+func newPriceIndex(itineraries []*entities.Itinerary, passengers entities.Passengers) *orderedIndex {
+	return newOrderedIndex(itineraries, func(itinerary *entities.Itinerary) decimal.Decimal {
+		return itinerary.GetBasePrice(passengers)
+	})
+}
+
+func newDurationIndex(itineraries []*entities.Itinerary) *orderedIndex {
+	return newOrderedIndex(itineraries, func(itinerary *entities.Itinerary) decimal.Decimal {
+		return decimal.NewFromInt(itinerary.GetDuration())
+	})
+}
+
+func newOrderedIndex(itineraries []*entities.Itinerary, getKey func(itinerary *entities.Itinerary) decimal.Decimal) *orderedIndex {
+	entries := make([]indexEntry, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		entries = append(entries, indexEntry{key: getKey(itinerary), itinerary: itinerary})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].less(entries[j])
+	})
+	return &orderedIndex{entries: entries}
+}
+
+// top returns up to limit itineraries from the lowest or the highest key,
+// itineraries with equal keys come by UUID in both directions.
+func (i *orderedIndex) top(limit int, descending bool) []*entities.Itinerary {
+	if limit > len(i.entries) {
+		limit = len(i.entries)
+	}
+	result := make([]*entities.Itinerary, 0, limit)
+	if !descending {
+		for j := 0; j < limit; j++ {
+			result = append(result, i.entries[j].itinerary)
+		}
+		return result
+	}
+
+	for end := len(i.entries); end > 0 && len(result) < limit; {
+		start := end - 1
+		for start > 0 && i.entries[start-1].key.Equal(i.entries[end-1].key) {
+			start--
+		}
+		for j := start; j < end && len(result) < limit; j++ {
+			result = append(result, i.entries[j].itinerary)
+		}
+		end = start
+	}
+	return result
+}
+
+func (e indexEntry) less(other indexEntry) bool {
+	if cmp := e.key.Cmp(other.key); cmp != 0 {
+		return cmp < 0
+	}
+	return e.itinerary.UUID < other.itinerary.UUID
+}
+
+// bucket keeps itineraries of a city pair or a response. Indexes are
+// built on the first read after the set is changed, so inserts don't rank
+// the whole set again.
+type bucket struct {
+	strategy    scoring.IStrategy
+	passengers  entities.Passengers
+	itineraries []*entities.Itinerary
+	// positions keeps indexes of itineraries by UUID.
+	positions map[entities.ItineraryUUID]int
+	// view is nil when itineraries changed after it was built.
+	view         *bucketView
+	isRefreshing sync.Mutex
+}
+
+// bucketView is a snapshot of the bucket with ordered indexes, so top N
+// of every ranking is read without sorting. It isn't changed once built.
+type bucketView struct {
+	itineraries *entities.Itineraries
+	byPrice     *orderedIndex
+	byDuration  *orderedIndex
+	byScore     []*entities.Itinerary
+}
+
+func newBucket(strategy scoring.IStrategy, passengers entities.Passengers) *bucket {
+	return &bucket{
+		strategy:    strategy,
+		passengers:  passengers,
+		itineraries: []*entities.Itinerary{},
+		positions:   map[entities.ItineraryUUID]int{},
+	}
+}
+
+// insert, replace and remove are called under the storage write lock,
+// readers don't see the bucket meanwhile.
+func (b *bucket) insert(itinerary *entities.Itinerary) {
+	b.positions[itinerary.UUID] = len(b.itineraries)
+	b.itineraries = append(b.itineraries, itinerary)
+	b.view = nil
+}
+
+// replace puts itinerary in place of the one with the same UUID.
+func (b *bucket) replace(itinerary *entities.Itinerary) {
+	position, ok := b.positions[itinerary.UUID]
+	if !ok {
+		b.insert(itinerary)
+		return
+	}
+	b.itineraries[position] = itinerary
+	b.view = nil
+}
+
+func (b *bucket) remove(uuids map[entities.ItineraryUUID]bool) {
+	itineraries := make([]*entities.Itinerary, 0, len(b.itineraries))
+	b.positions = make(map[entities.ItineraryUUID]int, len(b.itineraries))
+	for _, itinerary := range b.itineraries {
+		if !uuids[itinerary.UUID] {
+			b.positions[itinerary.UUID] = len(itineraries)
+			itineraries = append(itineraries, itinerary)
+		}
+	}
+	b.itineraries = itineraries
+	b.view = nil
+}
+
+func (b *bucket) find(uuid entities.ItineraryUUID) (*entities.Itinerary, bool) {
+	position, ok := b.positions[uuid]
+	if !ok {
+		return nil, false
+	}
+	return b.itineraries[position], true
+}
+
+// get returns the view of the bucket, it's built again when itineraries
+// changed. Readers of the storage may call it concurrently.
+func (b *bucket) get() *bucketView {
+	b.isRefreshing.Lock()
+	defer b.isRefreshing.Unlock()
+
+	if b.view == nil {
+		b.view = newBucketView(b.itineraries, b.strategy, b.passengers)
+	}
+	return b.view
+}
+
+// newBucketView ranks itineraries by strategy score and updates picks,
+// itineraries without base rate are ranked by duration only.
+func newBucketView(itineraries []*entities.Itinerary, strategy scoring.IStrategy, passengers entities.Passengers) *bucketView {
+	basePriced := entities.GetBasePriced(itineraries)
+	result := &bucketView{
+		itineraries: newItineraries(),
+		byPrice:     newPriceIndex(basePriced, passengers),
+		byDuration:  newDurationIndex(itineraries),
+	}
+	result.itineraries.Itineraries = append(result.itineraries.Itineraries, itineraries...)
+
+	ranked := strategy.Rank(basePriced, passengers)
+	result.byScore = make([]*entities.Itinerary, 0, len(ranked))
+	for _, item := range ranked {
+		result.byScore = append(result.byScore, item.Itinerary)
+	}
+
+	result.itineraries.Cheapest = getFirst(result.top(RankingCheapest, 1))
+	result.itineraries.MostExpensive = getFirst(result.top(RankingMostExpensive, 1))
+	result.itineraries.Shortest = getFirst(result.top(RankingShortest, 1))
+	result.itineraries.Longest = getFirst(result.top(RankingLongest, 1))
+	result.itineraries.Optimal = getFirst(result.top(RankingOptimal, 1))
+	return result
+}
+
+func newItineraries() *entities.Itineraries {
+	return &entities.Itineraries{
+		Itineraries:   []*entities.Itinerary{},
+		Shortest:      nil,
+		Longest:       nil,
+		Cheapest:      nil,
+		MostExpensive: nil,
+		Optimal:       nil,
+	}
+}
+
+func (b *bucketView) top(ranking string, limit int) []*entities.Itinerary {
+	switch ranking {
+	case RankingCheapest:
+		return b.byPrice.top(limit, false)
+	case RankingMostExpensive:
+		return b.byPrice.top(limit, true)
+	case RankingShortest:
+		return b.byDuration.top(limit, false)
+	case RankingLongest:
+		return b.byDuration.top(limit, true)
+	case RankingOptimal:
+		if limit > len(b.byScore) {
+			limit = len(b.byScore)
+		}
+		result := make([]*entities.Itinerary, limit)
+		copy(result, b.byScore)
+		return result
+	}
+	return nil
+}
+
+func getFirst(itineraries []*entities.Itinerary) *entities.Itinerary {
+	if len(itineraries) == 0 {
+		return nil
+	}
+	return itineraries[0]
+}
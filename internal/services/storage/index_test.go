@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderedIndex_Top(t *testing.T) {
+	keys := map[entities.ItineraryUUID]int64{"a": 20, "b": 10, "c": 20, "d": 30, "e": 10}
+	itineraries := []*entities.Itinerary{}
+	for _, uuid := range []entities.ItineraryUUID{"d", "c", "e", "a", "b"} {
+		itineraries = append(itineraries, &entities.Itinerary{UUID: uuid})
+	}
+	index := newOrderedIndex(itineraries, func(itinerary *entities.Itinerary) decimal.Decimal {
+		return decimal.NewFromInt(keys[itinerary.UUID])
+	})
+
+	items := map[string]struct {
+		limit      int
+		descending bool
+		expected   []entities.ItineraryUUID
+	}{
+		"it should return lowest keys by UUID": {
+			limit:    3,
+			expected: []entities.ItineraryUUID{"b", "e", "a"},
+		},
+		"it should return highest keys, equal ones by UUID": {
+			limit:      4,
+			descending: true,
+			expected:   []entities.ItineraryUUID{"d", "a", "c", "b"},
+		},
+		"it should return all itineraries when limit exceeds them": {
+			limit:      10,
+			descending: true,
+			expected:   []entities.ItineraryUUID{"d", "a", "c", "b", "e"},
+		},
+	}
+
+	for message, item := range items {
+		uuids := []entities.ItineraryUUID{}
+		for _, itinerary := range index.top(item.limit, item.descending) {
+			uuids = append(uuids, itinerary.UUID)
+		}
+		assert.Equal(t, item.expected, uuids, message)
+	}
+}
+
+func TestBucket_Get(t *testing.T) {
+	b := newBucket(scoring.NewWeighted(scoring.DefaultWeights), entities.Passengers{}.OrDefault())
+	b.insert(&itineraries[0])
+	view := b.get()
+	assert.Same(t, view, b.get(), "it should keep the view until itineraries change")
+	assert.Len(t, view.itineraries.Itineraries, 1)
+
+	b.insert(&itineraries[1])
+	assert.Len(t, view.itineraries.Itineraries, 1, "it should not change the built view")
+	assert.Len(t, b.get().itineraries.Itineraries, 2, "it should build the view again after insert")
+	assert.Equal(t, &itineraries[1], b.get().itineraries.Cheapest)
+}
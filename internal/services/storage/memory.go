@@ -1,128 +1,255 @@
 package storage
 
 import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
 	"aviasales/pkg/entities"
 	"aviasales/pkg/logger"
 	"context"
 	"errors"
+	"sort"
 	"sync"
-
-	uuid "github.com/satori/go.uuid"
-	"github.com/shopspring/decimal"
 )
 
 type service struct {
 	ctx            context.Context
-	Itineraries    map[entities.SourceCity]map[entities.DestinationCity]*entities.Itineraries
+	Itineraries    map[entities.SourceCity]map[entities.DestinationCity]*bucket
 	ItinerariesMap map[entities.ItineraryUUID]entities.Itinerary
-	isUpdating     sync.RWMutex
+	Responses      map[entities.ResponseID]*bucket
+	ResponsesMap   map[entities.ResponseID]entities.SearchResponse
+	ResponsesUUIDs map[entities.ResponseID]map[entities.ItineraryUUID]struct{}
+	// OffersResponses keeps responses of every offer, city pair buckets
+	// keep an offer once.
+	OffersResponses map[entities.ItineraryUUID]map[entities.ResponseID]struct{}
+	scoring         scoring.IStrategy
+	currency        currency.IService
+	// version counts updates of itineraries.
+	version    uint64
+	isUpdating sync.RWMutex
 }
 
-func NewMemoryStorage(ctx context.Context) *service {
-	itineraries := make(map[entities.SourceCity]map[entities.DestinationCity]*entities.Itineraries)
+func NewMemoryStorage(ctx context.Context, currency currency.IService, strategy scoring.IStrategy) *service {
+	itineraries := make(map[entities.SourceCity]map[entities.DestinationCity]*bucket)
 	return &service{
-		ctx:            ctx,
-		Itineraries:    itineraries,
-		ItinerariesMap: map[entities.ItineraryUUID]entities.Itinerary{},
+		ctx:             ctx,
+		Itineraries:     itineraries,
+		ItinerariesMap:  map[entities.ItineraryUUID]entities.Itinerary{},
+		Responses:       map[entities.ResponseID]*bucket{},
+		ResponsesMap:    map[entities.ResponseID]entities.SearchResponse{},
+		ResponsesUUIDs:  map[entities.ResponseID]map[entities.ItineraryUUID]struct{}{},
+		OffersResponses: map[entities.ItineraryUUID]map[entities.ResponseID]struct{}{},
+		scoring:         strategy,
+		currency:        currency,
 	}
 }
 
-func (s *service) AddItinerary(itinerary entities.Itinerary) {
+// AddItinerary stores itinerary under its content UUID. The same offer
+// may come in several responses, then city pair and GetByUUID have the
+// latest one.
+func (s *service) AddItinerary(itinerary entities.Itinerary) error {
 	s.isUpdating.Lock()
 	defer s.isUpdating.Unlock()
 
+	if err := s.checkItinerary(&itinerary); err != nil {
+		return err
+	}
+	s.addItinerary(itinerary)
+	s.version++
+	return nil
+}
+
+// AddItineraries stores itineraries of a batch, errors are of itineraries
+// with the same index, nil for stored ones.
+func (s *service) AddItineraries(itineraries []entities.Itinerary) []error {
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	errs := make([]error, len(itineraries))
+	for k := range itineraries {
+		if errs[k] = s.checkItinerary(&itineraries[k]); errs[k] == nil {
+			s.addItinerary(itineraries[k])
+		}
+	}
+	s.version++
+	return errs
+}
+
+// checkItinerary tells whether itinerary can be stored.
+func (s *service) checkItinerary(itinerary *entities.Itinerary) error {
 	if len(itinerary.Onward) == 0 {
 		logger.Debug(s.ctx, "unable to find source point")
-		return
+		return ErrEmptyItinerary
 	}
 
+	itineraryUUID := itinerary.GetContentUUID()
+	if _, ok := s.ResponsesUUIDs[itinerary.ResponseID][itineraryUUID]; ok {
+		logger.Debug(s.ctx, "duplicate itinerary", "uuid", itineraryUUID)
+		return ErrDuplicateItinerary
+	}
+	return nil
+}
+
+// addItinerary indexes itinerary passed checkItinerary.
+func (s *service) addItinerary(itinerary entities.Itinerary) {
+	// without rates prices are compared as they are, itineraries priced in
+	// a currency without rate are kept out of price rankings only
+	if itinerary.Pricing != nil && s.currency.GetBase() != "" {
+		rate, err := s.currency.GetRate(itinerary.Pricing.Currency, s.currency.GetBase())
+		if err != nil {
+			logger.Warn(s.ctx, "unable to normalize price", "currency", itinerary.Pricing.Currency, "error", err.Error())
+			itinerary.NoBaseRate = true
+		}
+		itinerary.BaseRate = rate
+	}
+
+	itineraryUUID := itinerary.GetContentUUID()
+	_, ok := s.ResponsesUUIDs[itinerary.ResponseID]
+	if !ok {
+		s.ResponsesUUIDs[itinerary.ResponseID] = map[entities.ItineraryUUID]struct{}{}
+	}
+	s.ResponsesUUIDs[itinerary.ResponseID][itineraryUUID] = struct{}{}
+
 	sourcePoint := entities.SourceCity(itinerary.Onward[0].Source)
-	_, ok := s.Itineraries[sourcePoint]
+	_, ok = s.Itineraries[sourcePoint]
 	if !ok {
-		s.Itineraries[sourcePoint] = make(map[entities.DestinationCity]*entities.Itineraries)
+		s.Itineraries[sourcePoint] = make(map[entities.DestinationCity]*bucket)
 	}
 
 	destinationPoint := entities.DestinationCity(itinerary.Onward[len(itinerary.Onward)-1].Destination)
+	passengers := entities.Passengers{}.OrDefault()
 	_, ok = s.Itineraries[sourcePoint][destinationPoint]
 	if !ok {
-		s.Itineraries[sourcePoint][destinationPoint] = &entities.Itineraries{
-			Itineraries:   []*entities.Itinerary{},
-			Shortest:      nil,
-			Longest:       nil,
-			Cheapest:      nil,
-			MostExpensive: nil,
-			Optimal:       nil,
-		}
+		s.Itineraries[sourcePoint][destinationPoint] = newBucket(s.scoring, passengers)
+	}
+
+	_, ok = s.Responses[itinerary.ResponseID]
+	if !ok {
+		s.Responses[itinerary.ResponseID] = newBucket(s.scoring, passengers)
 	}
 
-	itineraryUUID := entities.ItineraryUUID(uuid.NewV4().String())
 	itinerary.UUID = itineraryUUID
 	s.ItinerariesMap[itineraryUUID] = itinerary
 
-	itineraries := s.Itineraries[sourcePoint][destinationPoint]
-	itineraries.Itineraries = append(itineraries.Itineraries, &itinerary)
-	itineraries.Cheapest = getCheapest(itineraries.Cheapest, &itinerary, entities.ChargeTypeTotalAmount, entities.TypeSingleAdult)
-	itineraries.MostExpensive = getMostExpensiveCheapest(itineraries.MostExpensive, &itinerary, entities.ChargeTypeTotalAmount, entities.TypeSingleAdult)
-	itineraries.Shortest = getShortest(itineraries.Shortest, &itinerary)
-	itineraries.Longest = getLongest(itineraries.Longest, &itinerary)
-	itineraries.Optimal = getOptimal(itineraries.Optimal, &itinerary)
+	if _, ok = s.OffersResponses[itineraryUUID]; !ok {
+		s.OffersResponses[itineraryUUID] = map[entities.ResponseID]struct{}{}
+	}
+	s.OffersResponses[itineraryUUID][itinerary.ResponseID] = struct{}{}
+	s.Itineraries[sourcePoint][destinationPoint].replace(&itinerary)
+	s.Responses[itinerary.ResponseID].insert(&itinerary)
 }
 
-func (s *service) GetItineraries(source, destination string) ([]*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetItineraries(sources, destinations []string, filter *entities.ItineraryFilter) ([]*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return []*entities.Itinerary{}, nil
 	}
 
-	return itineraries.Itineraries, nil
+	return itineraries.itineraries.Itineraries, nil
+}
+
+// GetTop returns up to limit best itineraries of the ranking, itineraries
+// with equal values come by UUID.
+func (s *service) GetTop(sources, destinations []string, ranking string, limit int, filter *entities.ItineraryFilter) ([]*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
+	if !ok || limit <= 0 {
+		return []*entities.Itinerary{}, nil
+	}
+
+	result := itineraries.top(ranking, limit)
+	if result == nil {
+		return nil, ErrUnknownRanking
+	}
+	return result, nil
 }
 
-func (s *service) GetCheapest(source, destination string) (*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetCheapest(sources, destinations []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return &entities.Itinerary{}, nil
 	}
 
-	return itineraries.Cheapest, nil
+	return itineraries.itineraries.Cheapest, nil
 }
 
-func (s *service) GetMostExpensive(source, destination string) (*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetMostExpensive(sources, destinations []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return &entities.Itinerary{}, nil
 	}
 
-	return itineraries.MostExpensive, nil
+	return itineraries.itineraries.MostExpensive, nil
 }
 
-func (s *service) GetLongest(source, destination string) (*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetLongest(sources, destinations []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return &entities.Itinerary{}, nil
 	}
 
-	return itineraries.Longest, nil
+	return itineraries.itineraries.Longest, nil
 }
 
-func (s *service) GetShortest(source, destination string) (*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetShortest(sources, destinations []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return &entities.Itinerary{}, nil
 	}
 
-	return itineraries.Shortest, nil
+	return itineraries.itineraries.Shortest, nil
 }
 
-func (s *service) GetOptimal(source, destination string) (*entities.Itinerary, error) {
-	itineraries, ok := s.Itineraries[entities.SourceCity(source)][entities.DestinationCity(destination)]
+func (s *service) GetOptimal(sources, destinations []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error) {
+	itineraries, ok := s.getItineraries(sources, destinations, filter)
 	if !ok {
 		return &entities.Itinerary{}, nil
 	}
 
-	return itineraries.Optimal, nil
+	return itineraries.itineraries.Optimal, nil
+}
+
+// getItineraries returns city pair itineraries with indexes. Indexes of
+// a filtered set or of several pairs are built over matched itineraries only.
+func (s *service) getItineraries(sources, destinations []string, filter *entities.ItineraryFilter) (*bucketView, bool) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	if len(sources) == 1 && len(destinations) == 1 {
+		itineraries, ok := s.Itineraries[entities.SourceCity(sources[0])][entities.DestinationCity(destinations[0])]
+		if !ok {
+			return nil, false
+		}
+		if filter.IsEmpty() {
+			return itineraries.get(), true
+		}
+	}
+
+	found := false
+	result := newBucket(s.scoring, filter.GetPassengers())
+	for _, sourcePoint := range sources {
+		for _, destinationPoint := range destinations {
+			itineraries, ok := s.Itineraries[entities.SourceCity(sourcePoint)][entities.DestinationCity(destinationPoint)]
+			if !ok {
+				continue
+			}
+			found = true
+			for _, itinerary := range itineraries.itineraries {
+				if filter.Match(itinerary) {
+					result.insert(itinerary)
+				}
+			}
+		}
+	}
+	if !found {
+		return nil, false
+	}
+
+	return result.get(), true
 }
 
 func (s *service) GetByUUID(uuid string) (*entities.Itinerary, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
 	itinerary, ok := s.ItinerariesMap[entities.ItineraryUUID(uuid)]
 	if !ok {
 		return nil, errors.New("unable to find ticket")
@@ -131,97 +258,215 @@ func (s *service) GetByUUID(uuid string) (*entities.Itinerary, error) {
 	return &itinerary, nil
 }
 
-func getCheapest(itinerary1, itinerary2 *entities.Itinerary, chargeType, rateType string) *entities.Itinerary {
-	if itinerary1 == nil {
-		return itinerary2
+func (s *service) GetAllItineraries() ([]*entities.Itinerary, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	result := make([]*entities.Itinerary, 0, len(s.ItinerariesMap))
+	for uuid := range s.ItinerariesMap {
+		itinerary := s.ItinerariesMap[uuid]
+		result = append(result, &itinerary)
 	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UUID < result[j].UUID
+	})
+
+	return result, nil
+}
 
-	if itinerary1.GetPrice(chargeType, rateType).GreaterThan(itinerary2.GetPrice(chargeType, rateType)) {
-		return itinerary2
+func (s *service) GetRoutes() ([]*Route, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	result := []*Route{}
+	for sourcePoint, destinations := range s.Itineraries {
+		for destinationPoint, itineraries := range destinations {
+			if len(itineraries.itineraries) == 0 {
+				continue
+			}
+			result = append(result, newRoute(string(sourcePoint), string(destinationPoint), itineraries.get().itineraries, s.currency.GetBase()))
+		}
 	}
 
-	return itinerary1
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Source != result[j].Source {
+			return result[i].Source < result[j].Source
+		}
+		return result[i].Destination < result[j].Destination
+	})
+	return result, nil
 }
 
-func getMostExpensiveCheapest(itinerary1, itinerary2 *entities.Itinerary, chargeType, rateType string) *entities.Itinerary {
-	if itinerary1 == nil {
-		return itinerary2
+// newRoute prices the cheapest itinerary in the base currency like rankings,
+// without rates it's in the currency of the partner.
+func newRoute(source, destination string, itineraries *entities.Itineraries, baseCurrency string) *Route {
+	result := &Route{
+		Source:      source,
+		Destination: destination,
+		Count:       len(itineraries.Itineraries),
+	}
+	if cheapest := itineraries.Cheapest; cheapest != nil {
+		result.Cheapest = cheapest.UUID
+		result.CheapestPrice = cheapest.GetBasePrice(entities.Passengers{}.OrDefault())
+		result.Currency = baseCurrency
+		if baseCurrency == "" && cheapest.Pricing != nil {
+			result.Currency = cheapest.Pricing.Currency
+		}
+	}
+	for _, itinerary := range itineraries.Itineraries {
+		departure := itinerary.Onward[0].DepartureTimeStamp.Time
+		if result.FirstDeparture.IsZero() || departure.Before(result.FirstDeparture) {
+			result.FirstDeparture = departure
+		}
+		if departure.After(result.LastDeparture) {
+			result.LastDeparture = departure
+		}
 	}
+	return result
+}
 
-	if itinerary1.GetPrice(chargeType, rateType).GreaterThan(itinerary2.GetPrice(chargeType, rateType)) {
-		return itinerary1
+// GetResponseItineraries returns a copy of response itineraries with picks.
+func (s *service) GetResponseItineraries(responseID string) (*entities.Itineraries, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	itineraries, ok := s.Responses[entities.ResponseID(responseID)]
+	if !ok {
+		return nil, errors.New("unable to find response")
 	}
 
-	return itinerary2
+	// the view is shared by readers, callers get their own copy
+	result := *itineraries.get().itineraries
+	result.Itineraries = append([]*entities.Itinerary{}, result.Itineraries...)
+	return &result, nil
 }
 
-func getShortest(itinerary1, itinerary2 *entities.Itinerary) *entities.Itinerary {
-	if itinerary1 == nil {
-		return itinerary2
-	}
+func (s *service) AddResponse(response entities.SearchResponse) {
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	s.ResponsesMap[response.ID] = response
+}
 
-	if itinerary1.GetDuration() < itinerary2.GetDuration() {
-		return itinerary1
+func (s *service) GetResponses() ([]*entities.SearchResponse, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	responses := make([]*entities.SearchResponse, 0, len(s.ResponsesMap))
+	for id := range s.ResponsesMap {
+		response := s.ResponsesMap[id]
+		responses = append(responses, &response)
 	}
+	sort.Slice(responses, func(i, j int) bool {
+		return responses[i].ID < responses[j].ID
+	})
 
-	return itinerary2
+	return responses, nil
 }
 
-func getLongest(itinerary1, itinerary2 *entities.Itinerary) *entities.Itinerary {
-	if itinerary1 == nil {
-		return itinerary2
-	}
+func (s *service) GetResponse(responseID string) (*entities.SearchResponse, error) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
 
-	if itinerary1.GetDuration() > itinerary2.GetDuration() {
-		return itinerary1
+	response, ok := s.ResponsesMap[entities.ResponseID(responseID)]
+	if !ok {
+		return nil, errors.New("unable to find response")
 	}
 
-	return itinerary2
+	return &response, nil
 }
 
-func getOptimal(itinerary1, itinerary2 *entities.Itinerary) *entities.Itinerary {
-	if itinerary1 == nil {
-		return itinerary2
-	}
+// hasResponse tells whether the response or its itineraries are stored.
+func (s *service) hasResponse(responseID string) bool {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	_, hasItineraries := s.Responses[entities.ResponseID(responseID)]
+	_, hasResponse := s.ResponsesMap[entities.ResponseID(responseID)]
+	return hasItineraries || hasResponse
+}
 
-	score1, score2 := 0, 0
-	var coefficient1 decimal.Decimal
-	var coefficient2 decimal.Decimal
-	if itinerary1.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).GreaterThan(decimal.NewFromInt(0)) {
-		coefficient1 = decimal.NewFromInt(itinerary1.GetDurationWithoutTransfer()).Div(itinerary1.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult))
+// RemoveResponse retires the response with its itineraries, an offer
+// stays in its city pair while another response has it.
+func (s *service) RemoveResponse(responseID string) error {
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	id := entities.ResponseID(responseID)
+	removed, hasItineraries := s.Responses[id]
+	if _, hasResponse := s.ResponsesMap[id]; !hasItineraries && !hasResponse {
+		return errors.New("unable to find response")
 	}
-	if itinerary2.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).GreaterThan(decimal.NewFromInt(0)) {
-		coefficient2 = decimal.NewFromInt(itinerary2.GetDurationWithoutTransfer()).Div(itinerary2.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult))
+	delete(s.Responses, id)
+	delete(s.ResponsesMap, id)
+	delete(s.ResponsesUUIDs, id)
+	if !hasItineraries {
+		return nil
 	}
+	s.version++
 
-	if coefficient1.GreaterThan(coefficient2) {
-		score1++
-	} else {
-		score2++
+	// the same offer may stay loaded from another response
+	type pairKey struct {
+		source      entities.SourceCity
+		destination entities.DestinationCity
 	}
-
-	if itinerary1.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).
-		LessThan(itinerary2.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult)) {
-		score1++
-	} else {
-		score2++
+	retired := map[pairKey]map[entities.ItineraryUUID]bool{}
+	for _, itinerary := range removed.itineraries {
+		key := pairKey{
+			source:      entities.SourceCity(itinerary.Onward[0].Source),
+			destination: entities.DestinationCity(itinerary.Onward[len(itinerary.Onward)-1].Destination),
+		}
+		pair := s.Itineraries[key.source][key.destination]
+		responses := s.OffersResponses[itinerary.UUID]
+		delete(responses, id)
+		if len(responses) == 0 {
+			delete(s.OffersResponses, itinerary.UUID)
+			delete(s.ItinerariesMap, itinerary.UUID)
+			if _, ok := retired[key]; !ok {
+				retired[key] = map[entities.ItineraryUUID]bool{}
+			}
+			retired[key][itinerary.UUID] = true
+			continue
+		}
+		if current, ok := pair.find(itinerary.UUID); ok && current.ResponseID == id {
+			fallback := s.getOffer(itinerary.UUID, responses)
+			pair.replace(fallback)
+			s.ItinerariesMap[itinerary.UUID] = *fallback
+		}
 	}
 
-	if itinerary1.GetDurationWithoutTransfer() < itinerary2.GetDurationWithoutTransfer() {
-		score1++
-	} else {
-		score2++
+	for key, uuids := range retired {
+		pair := s.Itineraries[key.source][key.destination]
+		pair.remove(uuids)
+		if len(pair.itineraries) == 0 {
+			delete(s.Itineraries[key.source], key.destination)
+		}
 	}
 
-	if itinerary1.GetTransferDuration() < itinerary2.GetTransferDuration() {
-		score1++
-	} else {
-		score2++
-	}
+	return nil
+}
+
+func (s *service) GetVersion() uint64 {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
 
-	if score1 > score2 {
-		return itinerary1
+	return s.version
+}
+
+// getOffer returns the offer loaded from the first of responses by ID.
+func (s *service) getOffer(uuid entities.ItineraryUUID, responses map[entities.ResponseID]struct{}) *entities.Itinerary {
+	ids := make([]entities.ResponseID, 0, len(responses))
+	for responseID := range responses {
+		ids = append(ids, responseID)
 	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	itinerary, _ := s.Responses[ids[0]].find(uuid)
+	return itinerary
+}
 
-	return itinerary2
+// Close does nothing, memory storage has nothing to release.
+func (s *service) Close() error {
+	return nil
 }
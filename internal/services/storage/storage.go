@@ -1,21 +1,85 @@
 package storage
 
 import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
 	"aviasales/pkg/entities"
 	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendBolt   = "bolt"
 )
 
+var (
+	ErrUnknownBackend     = errors.New("unknown storage backend")
+	ErrEmptyItinerary     = errors.New("itinerary has no onward flights")
+	ErrDuplicateItinerary = errors.New("itinerary is already loaded from the response")
+)
+
+// Route describes itineraries of a city pair. Cheapest price is of a single
+// adult in the base currency.
+type Route struct {
+	Source         string                 `json:"source"`
+	Destination    string                 `json:"destination"`
+	Count          int                    `json:"count"`
+	Cheapest       entities.ItineraryUUID `json:"cheapest"`
+	CheapestPrice  decimal.Decimal        `json:"cheapestPrice"`
+	Currency       string                 `json:"currency"`
+	FirstDeparture time.Time              `json:"firstDeparture"`
+	LastDeparture  time.Time              `json:"lastDeparture"`
+}
+
+// IStorage keeps itineraries by city pair, getters search every pair of the
+// start and destination airports.
 type IStorage interface {
-	AddItinerary(itinerary entities.Itinerary)
-	GetItineraries(start, destination string) ([]*entities.Itinerary, error)
-	GetCheapest(start, destination string) (*entities.Itinerary, error)
-	GetMostExpensive(start, destination string) (*entities.Itinerary, error)
-	GetLongest(start, destination string) (*entities.Itinerary, error)
-	GetShortest(start, destination string) (*entities.Itinerary, error)
-	GetOptimal(start, destination string) (*entities.Itinerary, error)
+	AddItinerary(itinerary entities.Itinerary) error
+	// AddItineraries stores itineraries of a batch, errors are of
+	// itineraries with the same index, nil for stored ones.
+	AddItineraries(itineraries []entities.Itinerary) []error
+	GetItineraries(start, destination []string, filter *entities.ItineraryFilter) ([]*entities.Itinerary, error)
+	GetCheapest(start, destination []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error)
+	GetMostExpensive(start, destination []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error)
+	GetLongest(start, destination []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error)
+	GetShortest(start, destination []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error)
+	GetOptimal(start, destination []string, filter *entities.ItineraryFilter) (*entities.Itinerary, error)
+	GetTop(start, destination []string, ranking string, limit int, filter *entities.ItineraryFilter) ([]*entities.Itinerary, error)
 	GetByUUID(UUID string) (*entities.Itinerary, error)
+	// GetAllItineraries returns one itinerary of every offer ordered by UUID.
+	GetAllItineraries() ([]*entities.Itinerary, error)
+	// GetRoutes returns city pairs of loaded itineraries ordered by source
+	// and destination.
+	GetRoutes() ([]*Route, error)
+	GetResponseItineraries(responseID string) (*entities.Itineraries, error)
+	AddResponse(response entities.SearchResponse)
+	GetResponses() ([]*entities.SearchResponse, error)
+	GetResponse(responseID string) (*entities.SearchResponse, error)
+	RemoveResponse(responseID string) error
+	// GetVersion changes when itineraries are added or removed, results
+	// built of stored itineraries are kept while it's the same.
+	GetVersion() uint64
+	// Close releases storage files, storage isn't used after it.
+	Close() error
 }
 
-func New(ctx context.Context) *service {
-	return NewMemoryStorage(ctx)
+// New opens storage of the backend, fileName is used by persistent backends.
+func New(
+	ctx context.Context,
+	backend string,
+	fileName string,
+	currency currency.IService,
+	strategy scoring.IStrategy,
+) (IStorage, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemoryStorage(ctx, currency, strategy), nil
+	case BackendBolt:
+		return NewBoltStorage(ctx, fileName, currency, strategy)
+	}
+	return nil, ErrUnknownBackend
 }
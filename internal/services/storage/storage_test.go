@@ -0,0 +1,643 @@
+package storage
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/scoring"
+	"aviasales/pkg/entities"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	source          = "DXB"
+	destination     = "BKK"
+	decimalEqualNum = 0
+)
+
+var (
+	sources      = []string{source}
+	destinations = []string{destination}
+)
+
+var itineraries = []entities.Itinerary{
+	entities.Itinerary{
+		UUID: "",
+		Onward: []entities.Flight{
+			entities.Flight{
+				Carrier:            entities.Carrier{},
+				FlightNumber:       "",
+				Source:             "DXB",
+				Destination:        "DEL",
+				DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 27, 00, 00, 00, 00, time.UTC)}, // 2018-10-27T0000
+				ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 27, 04, 00, 00, 00, time.UTC)}, // 2018-10-27T0400
+				Class:              "",
+				NumberOfStops:      "",
+				TicketType:         "",
+			},
+			entities.Flight{
+				Carrier:            entities.Carrier{},
+				FlightNumber:       "",
+				Source:             "DEL",
+				Destination:        "BKK",
+				DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 27, 13, 00, 00, 00, time.UTC)}, // 2018-10-27T1300
+				ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 27, 19, 00, 00, 00, time.UTC)}, // 2018-10-27T1900
+				Class:              "",
+				NumberOfStops:      "",
+				TicketType:         "",
+			},
+		},
+		Return: nil,
+		Pricing: &entities.Price{
+			Currency: "SGD",
+			ServiceCharges: []entities.Charge{
+				{
+					ChargeType: "TotalAmount",
+					Type:       "BaseFare",
+					Cost:       decimal.NewFromFloat(233.00),
+				},
+				{
+					ChargeType: "TotalAmount",
+					Type:       "AirlineTaxes",
+					Cost:       decimal.NewFromFloat(152.40),
+				},
+				{
+					ChargeType: "TotalAmount",
+					Type:       "SingleAdult",
+					Cost:       decimal.NewFromFloat(385.40),
+				},
+				{
+					ChargeType: "TotalAmount",
+					Type:       "SingleInfant",
+					Cost:       decimal.NewFromFloat(140.40),
+				},
+			},
+		},
+	},
+	entities.Itinerary{
+		UUID: "",
+		Onward: []entities.Flight{
+			entities.Flight{
+				Carrier:            entities.Carrier{},
+				FlightNumber:       "",
+				Source:             "DXB",
+				Destination:        "CAN",
+				DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 27, 01, 00, 00, 00, time.UTC)}, // 2018-10-27T0100
+				ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 27, 12, 00, 00, 00, time.UTC)}, // 2018-10-27T1200
+				Class:              "",
+				NumberOfStops:      "",
+				TicketType:         "",
+			},
+			entities.Flight{
+				Carrier:            entities.Carrier{},
+				FlightNumber:       "",
+				Source:             "CAN",
+				Destination:        "BKK",
+				DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 27, 14, 00, 00, 00, time.UTC)}, // 2018-10-27T1400
+				ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 27, 17, 00, 00, 00, time.UTC)}, // 2018-10-27T1700
+				Class:              "",
+				NumberOfStops:      "",
+				TicketType:         "",
+			},
+		},
+		Return: nil,
+		Pricing: &entities.Price{
+			Currency: "SGD",
+			ServiceCharges: []entities.Charge{
+				{
+					ChargeType: "BaseFare",
+					Type:       "SingleAdult",
+					Cost:       decimal.NewFromFloat(167.00),
+				},
+				{
+					ChargeType: "AirlineTaxes",
+					Type:       "SingleAdult",
+					Cost:       decimal.NewFromFloat(215.70),
+				},
+				{
+					ChargeType: "TotalAmount",
+					Type:       "SingleAdult",
+					Cost:       decimal.NewFromFloat(382.70),
+				},
+			},
+		},
+	},
+}
+
+// conformanceTests are run against every storage backend.
+var conformanceTests = map[string]func(t *testing.T, storage IStorage){
+	"GetCheapest":            testGetCheapest,
+	"GetMostExpensive":       testGetMostExpensive,
+	"GetLongest":             testGetLongest,
+	"GetShortest":            testGetShortest,
+	"AddItinerary":           testAddItinerary,
+	"GetCheapestTripType":    testGetCheapestTripType,
+	"GetCheapestPassengers":  testGetCheapestPassengers,
+	"GetCheapestCurrency":    testGetCheapestCurrency,
+	"RemoveResponse":         testRemoveResponse,
+	"GetTop":                 testGetTop,
+	"GetAllItineraries":      testGetAllItineraries,
+	"GetItinerariesAirports": testGetItinerariesAirports,
+	"GetRoutes":              testGetRoutes,
+	"AddItineraryResponses":  testAddItineraryResponses,
+	"AddItineraries":         testAddItineraries,
+	"ConcurrentGetByUUID":    testConcurrentGetByUUID,
+	"GetVersion":             testGetVersion,
+}
+
+func TestStorage_Conformance(t *testing.T) {
+	directory, err := ioutil.TempDir("", "storage")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	backends := map[string]func(name string) IStorage{
+		BackendMemory: func(name string) IStorage {
+			return NewMemoryStorage(context.Background(), newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+		},
+		BackendBolt: func(name string) IStorage {
+			storage, openErr := NewBoltStorage(context.Background(), filepath.Join(directory, name+".db"), newCurrency(), scoring.NewWeighted(scoring.DefaultWeights))
+			if openErr != nil {
+				t.Fatal(openErr)
+			}
+			return storage
+		},
+	}
+
+	for backend, newStorage := range backends {
+		for name, test := range conformanceTests {
+			t.Run(backend+"/"+name, func(t *testing.T) {
+				storage := newStorage(name)
+				defer storage.Close()
+				test(t, storage)
+			})
+		}
+	}
+}
+
+func newCurrency() currency.IService {
+	return currency.New(context.Background(), currency.NewStaticProvider(currency.Rates{
+		Base:  "SGD",
+		Rates: map[string]decimal.Decimal{"USD": decimal.NewFromFloat(0.5)},
+	}))
+}
+
+func testGetCheapest(t *testing.T, storage IStorage) {
+	items := map[string]struct {
+		expectedValue decimal.Decimal
+	}{
+		"it should be 382.70": {
+			expectedValue: decimal.NewFromFloat(382.70),
+		},
+	}
+
+	for i := range itineraries {
+		storage.AddItinerary(itineraries[i])
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetCheapest(sources, destinations, nil)
+		cmp := itinerary.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).Cmp(item.expectedValue)
+		assert.Equal(t, decimalEqualNum, cmp, message)
+	}
+}
+
+func testGetMostExpensive(t *testing.T, storage IStorage) {
+	items := map[string]struct {
+		expectedValue decimal.Decimal
+	}{
+		"it should be 385.40": {
+			expectedValue: decimal.NewFromFloat(385.40),
+		},
+	}
+
+	for i := range itineraries {
+		storage.AddItinerary(itineraries[i])
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetMostExpensive(sources, destinations, nil)
+		cmp := itinerary.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).Cmp(item.expectedValue)
+		assert.Equal(t, decimalEqualNum, cmp, message)
+	}
+}
+
+func testGetLongest(t *testing.T, storage IStorage) {
+	items := map[string]struct {
+		expectedValue int64
+	}{
+		"it should be 19 hours": {
+			expectedValue: int64(19 * time.Hour),
+		},
+	}
+
+	for i := range itineraries {
+		storage.AddItinerary(itineraries[i])
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetLongest(sources, destinations, nil)
+		assert.Equal(t, item.expectedValue, itinerary.GetDuration(), message)
+	}
+}
+
+func testGetShortest(t *testing.T, storage IStorage) {
+	items := map[string]struct {
+		expectedValue int64
+	}{
+		"it should be 16 hours": {
+			expectedValue: int64(16 * time.Hour),
+		},
+	}
+
+	for i := range itineraries {
+		storage.AddItinerary(itineraries[i])
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetShortest(sources, destinations, nil)
+		assert.Equal(t, item.expectedValue, itinerary.GetDuration(), message)
+	}
+}
+
+func testAddItinerary(t *testing.T, storage IStorage) {
+	itinerary := itineraries[0]
+	itinerary.ResponseID = "RS_ViaOW.xml"
+	assert.NoError(t, storage.AddItinerary(itinerary))
+	assert.Equal(t, ErrDuplicateItinerary, storage.AddItinerary(itinerary), "it should detect duplicate in the same response")
+
+	itinerary.ResponseID = "RS_Via-3.xml"
+	assert.NoError(t, storage.AddItinerary(itinerary), "it should accept the same offer from another response")
+	assert.Equal(t, ErrEmptyItinerary, storage.AddItinerary(entities.Itinerary{}))
+
+	result, err := storage.GetByUUID(string(itineraries[0].GetContentUUID()))
+	assert.NoError(t, err)
+	assert.Equal(t, itineraries[0].GetContentUUID(), result.UUID, "it should keep UUID derived from content")
+}
+
+func testGetCheapestTripType(t *testing.T, storage IStorage) {
+	roundTrip := itineraries[0]
+	roundTrip.Return = []entities.Flight{
+		{
+			Source:             "BKK",
+			Destination:        "DXB",
+			DepartureTimeStamp: entities.FlightDate{Time: time.Date(2018, 10, 30, 8, 0, 0, 0, time.UTC)},
+			ArrivalTimeStamp:   entities.FlightDate{Time: time.Date(2018, 10, 30, 14, 0, 0, 0, time.UTC)},
+		},
+	}
+
+	_ = storage.AddItinerary(itineraries[1])
+	_ = storage.AddItinerary(roundTrip)
+
+	items := map[string]struct {
+		filter        *entities.ItineraryFilter
+		expectedValue decimal.Decimal
+	}{
+		"it should be 382.70 without filter": {
+			filter:        nil,
+			expectedValue: decimal.NewFromFloat(382.70),
+		},
+		"it should be 385.40 for round trips": {
+			filter:        &entities.ItineraryFilter{TripType: entities.TripTypeRoundTrip},
+			expectedValue: decimal.NewFromFloat(385.40),
+		},
+		"it should be 385.40 for return date": {
+			filter:        &entities.ItineraryFilter{ReturnDate: time.Date(2018, 10, 30, 0, 0, 0, 0, time.UTC)},
+			expectedValue: decimal.NewFromFloat(385.40),
+		},
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetCheapest(sources, destinations, item.filter)
+		cmp := itinerary.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult).Cmp(item.expectedValue)
+		assert.Equal(t, decimalEqualNum, cmp, message)
+	}
+}
+
+func testGetCheapestPassengers(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		_ = storage.AddItinerary(itineraries[i])
+	}
+
+	items := map[string]struct {
+		passengers    entities.Passengers
+		expectedValue decimal.Decimal
+	}{
+		"it should be 382.70 for a single adult": {
+			passengers:    entities.Passengers{Adults: 1},
+			expectedValue: decimal.NewFromFloat(382.70),
+		},
+		"it should be 911.20 for 2 adults and an infant": {
+			passengers:    entities.Passengers{Adults: 2, Infants: 1},
+			expectedValue: decimal.NewFromFloat(911.20),
+		},
+	}
+
+	for message, item := range items {
+		itinerary, _ := storage.GetCheapest(sources, destinations, &entities.ItineraryFilter{Passengers: item.passengers})
+		cmp := itinerary.GetTotalPrice(item.passengers).Cmp(item.expectedValue)
+		assert.Equal(t, decimalEqualNum, cmp, message)
+	}
+}
+
+func testGetCheapestCurrency(t *testing.T, storage IStorage) {
+	dollars := itineraries[1]
+	dollars.Pricing = &entities.Price{
+		Currency: "USD",
+		ServiceCharges: []entities.Charge{
+			{ChargeType: entities.ChargeTypeTotalAmount, Type: entities.TypeSingleAdult, Cost: decimal.NewFromFloat(200)},
+		},
+	}
+	assert.NoError(t, storage.AddItinerary(itineraries[0]))
+	assert.NoError(t, storage.AddItinerary(dollars))
+
+	itinerary, _ := storage.GetCheapest(sources, destinations, nil)
+	assert.Equal(t, "SGD", itinerary.Pricing.Currency, "it should compare 385.40 SGD with 200 USD as 400 SGD")
+
+	euros := itineraries[1]
+	euros.Onward = append([]entities.Flight{}, euros.Onward...)
+	euros.Onward[0].FlightNumber = "EUR"
+	euros.Pricing = &entities.Price{
+		Currency: "EUR",
+		ServiceCharges: []entities.Charge{
+			{ChargeType: entities.ChargeTypeTotalAmount, Type: entities.TypeSingleAdult, Cost: decimal.NewFromFloat(1)},
+		},
+	}
+	assert.NoError(t, storage.AddItinerary(euros), "it should keep prices in unknown currency")
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	assert.Len(t, all, 3)
+	itinerary, _ = storage.GetCheapest(sources, destinations, nil)
+	assert.Equal(t, "SGD", itinerary.Pricing.Currency, "it should leave prices in unknown currency out of price rankings")
+	shortest, _ := storage.GetTop(sources, destinations, RankingShortest, 3, nil)
+	assert.Len(t, shortest, 3, "it should rank prices in unknown currency by duration")
+}
+
+func testRemoveResponse(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		itinerary := itineraries[i]
+		itinerary.ResponseID = "response1"
+		_ = storage.AddItinerary(itinerary)
+	}
+	replaced := itineraries[1]
+	replaced.ResponseID = "response2"
+	_ = storage.AddItinerary(replaced)
+	storage.AddResponse(entities.SearchResponse{ID: "response1"})
+	storage.AddResponse(entities.SearchResponse{ID: "response2"})
+
+	assert.NoError(t, storage.RemoveResponse("response2"))
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	assert.Len(t, all, 2, "it should keep itineraries of other responses")
+	itinerary, err := storage.GetByUUID(string(all[1].UUID))
+	assert.NoError(t, err)
+	assert.Equal(t, entities.ResponseID("response1"), itinerary.ResponseID, "it should fall back to another response of the offer")
+
+	assert.NoError(t, storage.RemoveResponse("response1"))
+	all, _ = storage.GetItineraries(sources, destinations, nil)
+	assert.Empty(t, all, "it should retire itineraries of the response")
+	_, err = storage.GetByUUID(string(itinerary.UUID))
+	assert.Error(t, err)
+	_, err = storage.GetResponse("response1")
+	assert.Error(t, err)
+
+	assert.Error(t, storage.RemoveResponse("response1"), "it should fail for unknown response")
+}
+
+func testGetTop(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		_ = storage.AddItinerary(itineraries[i])
+	}
+
+	items := map[string]struct {
+		ranking  string
+		limit    int
+		expected []decimal.Decimal
+	}{
+		"it should return cheapest first": {
+			ranking:  RankingCheapest,
+			limit:    2,
+			expected: []decimal.Decimal{decimal.NewFromFloat(382.70), decimal.NewFromFloat(385.40)},
+		},
+		"it should return most expensive first": {
+			ranking:  RankingMostExpensive,
+			limit:    1,
+			expected: []decimal.Decimal{decimal.NewFromFloat(385.40)},
+		},
+	}
+
+	for message, item := range items {
+		top, err := storage.GetTop(sources, destinations, item.ranking, item.limit, nil)
+		assert.NoError(t, err, message)
+		prices := []decimal.Decimal{}
+		for _, itinerary := range top {
+			prices = append(prices, itinerary.GetPrice(entities.ChargeTypeTotalAmount, entities.TypeSingleAdult))
+		}
+		assert.Equal(t, len(item.expected), len(prices), message)
+		for i := range prices {
+			assert.True(t, item.expected[i].Equal(prices[i]), message)
+		}
+	}
+
+	_, err := storage.GetTop(sources, destinations, "random", 1, nil)
+	assert.Equal(t, ErrUnknownRanking, err)
+}
+
+func testGetAllItineraries(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		itinerary := itineraries[i]
+		itinerary.ResponseID = "response1"
+		_ = storage.AddItinerary(itinerary)
+		itinerary.ResponseID = "response2"
+		_ = storage.AddItinerary(itinerary)
+	}
+
+	all, err := storage.GetAllItineraries()
+	assert.NoError(t, err)
+	if assert.Len(t, all, len(itineraries), "it should return every offer once") {
+		assert.True(t, all[0].UUID < all[1].UUID, "it should order itineraries by UUID")
+	}
+}
+
+func testGetItinerariesAirports(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		_ = storage.AddItinerary(itineraries[i])
+	}
+	// the second itinerary lands at the other Bangkok airport
+	itinerary := itineraries[1]
+	itinerary.Onward = append([]entities.Flight{}, itinerary.Onward...)
+	itinerary.Onward[1].FlightNumber = "FD1"
+	itinerary.Onward[1].Destination = "DMK"
+	itinerary.Pricing = &entities.Price{
+		Currency: "SGD",
+		ServiceCharges: []entities.Charge{
+			{
+				ChargeType: "TotalAmount",
+				Type:       "SingleAdult",
+				Cost:       decimal.NewFromFloat(300.00),
+			},
+		},
+	}
+	assert.NoError(t, storage.AddItinerary(itinerary))
+
+	items := map[string]struct {
+		destinations  []string
+		expectedCount int
+	}{
+		"it should return itineraries of the airport": {
+			destinations:  []string{"DMK"},
+			expectedCount: 1,
+		},
+		"it should return itineraries of all airports": {
+			destinations:  []string{"BKK", "DMK"},
+			expectedCount: 3,
+		},
+		"it should skip airports without itineraries": {
+			destinations:  []string{"BKK", "ZZZ"},
+			expectedCount: 2,
+		},
+		"it should return nothing for unknown airports": {
+			destinations:  []string{"ZZZ", "YYY"},
+			expectedCount: 0,
+		},
+	}
+
+	for message, item := range items {
+		result, err := storage.GetItineraries(sources, item.destinations, nil)
+		assert.NoError(t, err, message)
+		assert.Len(t, result, item.expectedCount, message)
+	}
+
+	cheapest, err := storage.GetCheapest(sources, []string{"BKK", "DMK"}, nil)
+	if assert.NoError(t, err) && assert.NotNil(t, cheapest) {
+		assert.Equal(t, "DMK", cheapest.Onward[1].Destination, "it should pick the cheapest of all airports")
+	}
+}
+
+func testGetRoutes(t *testing.T, storage IStorage) {
+	for i := range itineraries {
+		_ = storage.AddItinerary(itineraries[i])
+	}
+	itinerary := itineraries[0]
+	itinerary.Onward = itinerary.Onward[:1]
+	itinerary.Pricing = &entities.Price{
+		Currency: "USD",
+		ServiceCharges: []entities.Charge{
+			{ChargeType: entities.ChargeTypeTotalAmount, Type: entities.TypeSingleAdult, Cost: decimal.NewFromFloat(100)},
+		},
+	}
+	_ = storage.AddItinerary(itinerary)
+
+	routes, err := storage.GetRoutes()
+	assert.NoError(t, err)
+	if assert.Len(t, routes, 2, "it should return every city pair") {
+		assert.Equal(t, "BKK", routes[0].Destination, "it should order routes by destination")
+		assert.Equal(t, 2, routes[0].Count)
+		assert.True(t, decimal.NewFromFloat(382.70).Equal(routes[0].CheapestPrice), "it should return the cheapest price")
+		assert.Equal(t, "SGD", routes[0].Currency)
+		assert.Equal(t, time.Date(2018, 10, 27, 0, 0, 0, 0, time.UTC), routes[0].FirstDeparture.UTC())
+		assert.Equal(t, time.Date(2018, 10, 27, 1, 0, 0, 0, time.UTC), routes[0].LastDeparture.UTC())
+		assert.Equal(t, "DEL", routes[1].Destination)
+		assert.Equal(t, 1, routes[1].Count)
+		assert.Equal(t, "200", routes[1].CheapestPrice.String(), "it should return the cheapest price in the base currency")
+		assert.Equal(t, "SGD", routes[1].Currency)
+	}
+}
+
+func testAddItineraryResponses(t *testing.T, storage IStorage) {
+	for _, responseID := range []entities.ResponseID{"response1", "response2"} {
+		for i := range itineraries {
+			itinerary := itineraries[i]
+			itinerary.ResponseID = responseID
+			assert.NoError(t, storage.AddItinerary(itinerary), "it should add the offer of another response")
+		}
+		storage.AddResponse(entities.SearchResponse{ID: responseID})
+	}
+
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	assert.Len(t, all, len(itineraries), "it should keep the offer of several responses once")
+	for _, itinerary := range all {
+		assert.Equal(t, entities.ResponseID("response2"), itinerary.ResponseID, "it should keep the latest offer")
+	}
+	top, _ := storage.GetTop(sources, destinations, RankingCheapest, 10, nil)
+	assert.Len(t, top, len(itineraries))
+	response, _ := storage.GetResponseItineraries("response1")
+	assert.Len(t, response.Itineraries, len(itineraries), "it should keep offers of every response")
+
+	assert.NoError(t, storage.RemoveResponse("response2"))
+	all, _ = storage.GetItineraries(sources, destinations, nil)
+	if assert.Len(t, all, len(itineraries), "it should keep offers of the other response") {
+		assert.Equal(t, entities.ResponseID("response1"), all[0].ResponseID)
+	}
+	assert.NoError(t, storage.RemoveResponse("response1"))
+	all, _ = storage.GetItineraries(sources, destinations, nil)
+	assert.Empty(t, all, "it should remove offers without responses")
+}
+
+func testAddItineraries(t *testing.T, storage IStorage) {
+	batch := append([]entities.Itinerary{}, itineraries...)
+	batch = append(batch, itineraries[0], entities.Itinerary{})
+
+	errs := storage.AddItineraries(batch)
+	if assert.Len(t, errs, len(batch)) {
+		for k := range itineraries {
+			assert.NoError(t, errs[k])
+		}
+		assert.Equal(t, ErrDuplicateItinerary, errs[len(itineraries)], "it should reject duplicates of the batch")
+		assert.Equal(t, ErrEmptyItinerary, errs[len(itineraries)+1])
+	}
+	all, _ := storage.GetItineraries(sources, destinations, nil)
+	assert.Len(t, all, len(itineraries))
+	assert.Equal(t, []error{ErrDuplicateItinerary}, storage.AddItineraries(itineraries[:1]), "it should reject itineraries already stored")
+}
+
+// testConcurrentGetByUUID looks up itineraries while responses are added
+// and removed, it's meant to be run with -race.
+func testConcurrentGetByUUID(t *testing.T, storage IStorage) {
+	uuids := make([]string, 0, len(itineraries))
+	for i := range itineraries {
+		uuids = append(uuids, string(itineraries[i].GetContentUUID()))
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			batch := append([]entities.Itinerary{}, itineraries...)
+			for k := range batch {
+				batch[k].ResponseID = "response"
+			}
+			storage.AddItineraries(batch)
+			_ = storage.RemoveResponse("response")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		for _, uuid := range uuids {
+			_, _ = storage.GetByUUID(uuid)
+		}
+	}
+	wg.Wait()
+
+	_, err := storage.GetByUUID(uuids[0])
+	assert.Error(t, err, "it should remove itineraries of the removed response")
+}
+
+func testGetVersion(t *testing.T, storage IStorage) {
+	version := storage.GetVersion()
+	itinerary := itineraries[0]
+	itinerary.ResponseID = "response"
+	assert.NoError(t, storage.AddItinerary(itinerary))
+	assert.NotEqual(t, version, storage.GetVersion(), "it should change after itineraries are added")
+
+	version = storage.GetVersion()
+	storage.AddResponse(entities.SearchResponse{ID: "response"})
+	assert.Equal(t, version, storage.GetVersion(), "it should keep version when itineraries are the same")
+
+	assert.NoError(t, storage.RemoveResponse("response"))
+	assert.NotEqual(t, version, storage.GetVersion(), "it should change after itineraries are removed")
+}
@@ -0,0 +1,409 @@
+package watcher
+
+import (
+	"aviasales/internal/services/parser"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"aviasales/pkg/logger"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FileStatusParsing  = "parsing"
+	FileStatusIngested = "ingested"
+	FileStatusFailed   = "failed"
+	FileStatusDeleted  = "deleted"
+)
+
+const fileExtension = ".xml"
+
+// FileStatus describes a partner response file of a watched directory.
+type FileStatus struct {
+	FileName         string
+	ResponseID       entities.ResponseID
+	Status           string
+	ItinerariesCount int
+	ParseErrors      []entities.ParseError
+	Size             int64
+	ModifiedAt       time.Time
+	IngestedAt       time.Time
+	// replaced is the response of the previous file version, it's retired
+	// when the file is parsed.
+	replaced entities.ResponseID
+}
+
+// Progress summarizes ingestion of watched files, Ready is set when every
+// file found by the first scan is parsed.
+type Progress struct {
+	Ready            bool
+	FilesCount       int
+	ParsingCount     int
+	IngestedCount    int
+	FailedCount      int
+	ItinerariesCount int
+}
+
+type IService interface {
+	// Start ingests files of watched directories and keeps watching them
+	// until the context is done or Stop is called. It doesn't wait for the
+	// first scan, GetProgress tells when it's done.
+	Start()
+	// Stop stops watching, files being parsed are still updated.
+	Stop()
+	GetFiles() []*FileStatus
+	GetProgress() Progress
+}
+
+type service struct {
+	ctx         context.Context
+	directories []string
+	interval    time.Duration
+	strict      bool
+	storage     storage.IStorage
+	parser      parser.IPool
+	files       map[string]*FileStatus
+	isUpdating  sync.RWMutex
+	// initial holds files of the first scan which aren't parsed yet.
+	initial  map[string]bool
+	started  bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	watching sync.WaitGroup
+}
+
+func New(
+	ctx context.Context,
+	directories []string,
+	interval time.Duration,
+	strict bool,
+	storage storage.IStorage,
+	parser parser.IPool,
+) *service {
+	return &service{
+		ctx:         ctx,
+		directories: directories,
+		interval:    interval,
+		strict:      strict,
+		storage:     storage,
+		parser:      parser,
+		files:       map[string]*FileStatus{},
+		initial:     map[string]bool{},
+		stop:        make(chan struct{}),
+	}
+}
+
+func (s *service) Start() {
+	s.watching.Add(1)
+	go func() {
+		defer s.watching.Done()
+		s.restore()
+		s.scan()
+		s.startProgress()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.scan()
+			}
+		}
+	}()
+}
+
+// restore takes files parsed before restart from persistent storage,
+// so unchanged files aren't parsed again.
+func (s *service) restore() {
+	responses, err := s.storage.GetResponses()
+	if err != nil {
+		logger.Error(s.ctx, "unable to restore files", err)
+		return
+	}
+
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	for _, response := range responses {
+		if !s.isWatched(response.FileName) || response.FileSize == 0 {
+			continue
+		}
+		s.files[response.FileName] = &FileStatus{
+			FileName:         response.FileName,
+			ResponseID:       response.ID,
+			Status:           getStatus(response),
+			ItinerariesCount: response.ItinerariesCount,
+			ParseErrors:      response.ParseErrors,
+			Size:             response.FileSize,
+			ModifiedAt:       response.FileModifiedAt,
+		}
+	}
+	if len(s.files) > 0 {
+		logger.Info(s.ctx, "restored files", "count", len(s.files))
+	}
+}
+
+func (s *service) isWatched(fileName string) bool {
+	for _, directory := range s.directories {
+		if filepath.Dir(fileName) == filepath.Clean(directory) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *service) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	s.watching.Wait()
+}
+
+// startProgress remembers files of the first scan to report readiness.
+func (s *service) startProgress() {
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	for fileName, file := range s.files {
+		if file.Status == FileStatusParsing {
+			s.initial[fileName] = true
+		}
+	}
+	s.started = true
+	s.checkProgress()
+}
+
+// checkProgress logs the end of initial ingestion, it's called under lock.
+func (s *service) checkProgress() {
+	if s.started && len(s.initial) == 0 {
+		progress := s.getProgress()
+		logger.Info(s.ctx, "initial ingestion is finished",
+			"files", progress.FilesCount,
+			"failed", progress.FailedCount,
+			"itineraries", progress.ItinerariesCount,
+		)
+	}
+}
+
+func (s *service) GetProgress() Progress {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	return s.getProgress()
+}
+
+func (s *service) getProgress() Progress {
+	progress := Progress{
+		Ready: s.started && len(s.initial) == 0,
+	}
+	for _, file := range s.files {
+		if file.Status == FileStatusDeleted {
+			continue
+		}
+		progress.FilesCount++
+		progress.ItinerariesCount += file.ItinerariesCount
+		switch file.Status {
+		case FileStatusParsing:
+			progress.ParsingCount++
+		case FileStatusIngested:
+			progress.IngestedCount++
+		case FileStatusFailed:
+			progress.FailedCount++
+		}
+	}
+	return progress
+}
+
+func (s *service) GetFiles() []*FileStatus {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	result := make([]*FileStatus, 0, len(s.files))
+	for fileName := range s.files {
+		file := *s.files[fileName]
+		result = append(result, &file)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FileName < result[j].FileName
+	})
+	return result
+}
+
+// scan ingests new and replaced files and retires deleted ones.
+// A file is replaced when its size or modification time is changed,
+// files being parsed are checked again by the next scan.
+func (s *service) scan() {
+	found := map[string]bool{}
+	for _, directory := range s.directories {
+		files, err := ioutil.ReadDir(directory)
+		if err != nil {
+			logger.Error(s.ctx, "unable to read directory", err, "directory", directory)
+			// files of unreadable directory aren't retired
+			s.keepDirectory(directory, found)
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !strings.EqualFold(filepath.Ext(file.Name()), fileExtension) {
+				continue
+			}
+			fileName := filepath.Join(directory, file.Name())
+			found[fileName] = true
+			s.ingest(fileName, file.Size(), file.ModTime())
+		}
+	}
+
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	for fileName, file := range s.files {
+		if !found[fileName] && file.Status != FileStatusDeleted && file.Status != FileStatusParsing {
+			s.retire(file.ResponseID)
+			file.Status = FileStatusDeleted
+			logger.Info(s.ctx, "file is deleted", "fileName", fileName)
+		}
+	}
+}
+
+func (s *service) keepDirectory(directory string, found map[string]bool) {
+	s.isUpdating.RLock()
+	defer s.isUpdating.RUnlock()
+
+	for fileName := range s.files {
+		if filepath.Dir(fileName) == filepath.Clean(directory) {
+			found[fileName] = true
+		}
+	}
+}
+
+// ingest queues the file when it's new or replaced, the lock isn't held
+// while the parser waits for a free worker.
+func (s *service) ingest(fileName string, size int64, modifiedAt time.Time) {
+	file := s.register(fileName, size, modifiedAt)
+	if file == nil {
+		return
+	}
+
+	done := make(chan entities.SearchResponse, 1)
+	err := s.parser.Put(&parser.WorkerParserQueue{
+		Ctx:        s.ctx,
+		FileName:   fileName,
+		ResponseID: file.ResponseID,
+		Storage:    s.storage,
+		Done:       done,
+		Strict:     s.strict,
+	})
+	if err != nil {
+		s.isUpdating.Lock()
+		defer s.isUpdating.Unlock()
+
+		file.Status = FileStatusFailed
+		file.ParseErrors = append(file.ParseErrors, entities.ParseError{Message: err.Error()})
+		s.retireReplaced(file)
+		return
+	}
+
+	go s.wait(file, done)
+}
+
+// register returns status of the file to parse, nil when it's unchanged
+// or being parsed. A replaced file gets another response ID, so the previous
+// version stays searchable until the new one is parsed.
+func (s *service) register(fileName string, size int64, modifiedAt time.Time) *FileStatus {
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	file, ok := s.files[fileName]
+	if ok && file.Status == FileStatusParsing {
+		return nil
+	}
+	if ok && file.Status != FileStatusDeleted && file.Size == size && file.ModifiedAt.Equal(modifiedAt) {
+		return nil
+	}
+
+	for otherName, other := range s.files {
+		if otherName != fileName && filepath.Base(otherName) == filepath.Base(fileName) && other.Status != FileStatusDeleted {
+			logger.Warn(s.ctx, "file with the same name is already watched", "fileName", fileName, "other", otherName)
+			return nil
+		}
+	}
+
+	responseID := entities.ResponseID(filepath.Base(fileName))
+	var replaced entities.ResponseID
+	if ok && file.Status != FileStatusDeleted {
+		replaced = file.ResponseID
+	}
+	if replaced == responseID {
+		responseID = entities.ResponseID(fmt.Sprintf("%s~%d", responseID, modifiedAt.UnixNano()))
+	}
+	file = &FileStatus{
+		FileName:    fileName,
+		ResponseID:  responseID,
+		Status:      FileStatusParsing,
+		ParseErrors: []entities.ParseError{},
+		Size:        size,
+		ModifiedAt:  modifiedAt,
+		replaced:    replaced,
+	}
+	s.files[fileName] = file
+	return file
+}
+
+// wait updates status of the file when it's parsed and retires the previous
+// version of the file.
+func (s *service) wait(file *FileStatus, done <-chan entities.SearchResponse) {
+	var response entities.SearchResponse
+	select {
+	case response = <-done:
+	case <-s.ctx.Done():
+		return
+	}
+
+	s.isUpdating.Lock()
+	defer s.isUpdating.Unlock()
+
+	file.ItinerariesCount = response.ItinerariesCount
+	file.ParseErrors = response.ParseErrors
+	file.IngestedAt = time.Now()
+	file.Status = getStatus(&response)
+	s.retireReplaced(file)
+
+	if s.initial[file.FileName] {
+		delete(s.initial, file.FileName)
+		s.checkProgress()
+	}
+}
+
+func getStatus(response *entities.SearchResponse) string {
+	if response.Rejected || response.ItinerariesCount == 0 && len(response.ParseErrors) > 0 {
+		return FileStatusFailed
+	}
+	return FileStatusIngested
+}
+
+// retireReplaced removes the previous version of the file, it's called
+// under lock.
+func (s *service) retireReplaced(file *FileStatus) {
+	if file.replaced != "" {
+		s.retire(file.replaced)
+		file.replaced = ""
+	}
+}
+
+// retire removes the response with its itineraries, it's called under lock.
+func (s *service) retire(responseID entities.ResponseID) {
+	if err := s.storage.RemoveResponse(string(responseID)); err != nil {
+		logger.Warn(s.ctx, "unable to remove response", "responseID", responseID, "error", err.Error())
+	}
+}
@@ -0,0 +1,189 @@
+package watcher
+
+import (
+	"aviasales/internal/services/currency"
+	"aviasales/internal/services/parser"
+	"aviasales/internal/services/scoring"
+	"aviasales/internal/services/storage"
+	"aviasales/pkg/entities"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const flightXML = `
+		<Flights>
+			<OnwardPricedItinerary>
+				<Flights>
+					<Flight>
+						<Carrier id="EK">Emirates</Carrier>
+						<FlightNumber>%d</FlightNumber>
+						<Source>DXB</Source>
+						<Destination>BKK</Destination>
+						<DepartureTimeStamp>2018-10-27T0905</DepartureTimeStamp>
+						<ArrivalTimeStamp>2018-10-27T1805</ArrivalTimeStamp>
+					</Flight>
+				</Flights>
+			</OnwardPricedItinerary>
+			<Pricing currency="SGD">
+				<ServiceCharges type="SingleAdult" ChargeType="TotalAmount">811.70</ServiceCharges>
+			</Pricing>
+		</Flights>`
+
+func writeResponse(t *testing.T, fileName string, flightsCount int) {
+	flights := make([]string, 0, flightsCount)
+	for i := 0; i < flightsCount; i++ {
+		flights = append(flights, fmt.Sprintf(flightXML, 400+i))
+	}
+	data := "<AirFareSearchResponse><PricedItineraries>" + strings.Join(flights, "") + "</PricedItineraries></AirFareSearchResponse>"
+	assert.NoError(t, ioutil.WriteFile(fileName, []byte(data), 0600))
+}
+
+func TestService_Scan(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := parser.SpawnWorkers(1)
+	defer pool.Flush()
+	s := New(ctx, []string{directory}, time.Hour, false, store, pool)
+
+	fileName := filepath.Join(directory, "response.xml")
+	getFile := func() *FileStatus {
+		files := s.GetFiles()
+		if len(files) != 1 {
+			return &FileStatus{}
+		}
+		return files[0]
+	}
+	isIngested := func(count int) func() bool {
+		return func() bool {
+			return getFile().Status == FileStatusIngested && getFile().ItinerariesCount == count
+		}
+	}
+
+	writeResponse(t, fileName, 1)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(directory, "notes.txt"), []byte("skip"), 0600))
+	s.scan()
+	assert.Eventually(t, isIngested(1), time.Second, 10*time.Millisecond, "it should ingest a new file")
+
+	writeResponse(t, fileName, 2)
+	s.scan()
+	assert.Eventually(t, isIngested(2), time.Second, 10*time.Millisecond, "it should ingest a replaced file")
+	itineraries, _ := store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+	assert.Len(t, itineraries, 2, "it should retire itineraries of the replaced file")
+
+	assert.NoError(t, os.Remove(fileName))
+	s.scan()
+	assert.Equal(t, FileStatusDeleted, getFile().Status, "it should retire a deleted file")
+	itineraries, _ = store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+	assert.Empty(t, itineraries)
+}
+
+func TestService_Register_Replaced(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := parser.SpawnWorkers(1)
+	defer pool.Flush()
+	s := New(ctx, []string{directory}, time.Hour, false, store, pool)
+
+	fileName := filepath.Join(directory, "response.xml")
+	writeResponse(t, fileName, 1)
+	s.scan()
+	assert.Eventually(t, func() bool {
+		return s.GetProgress().IngestedCount == 1
+	}, time.Second, 10*time.Millisecond)
+
+	file := s.register(fileName, 1, time.Now())
+	if !assert.NotNil(t, file) {
+		return
+	}
+	assert.NotEqual(t, entities.ResponseID("response.xml"), file.ResponseID, "it should parse a replaced file under another response")
+	itineraries, _ := store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+	assert.Len(t, itineraries, 1, "it should keep the previous version while the file is parsed")
+
+	done := make(chan entities.SearchResponse, 1)
+	done <- entities.SearchResponse{ID: file.ResponseID}
+	s.wait(file, done)
+	itineraries, _ = store.GetItineraries([]string{"DXB"}, []string{"BKK"}, nil)
+	assert.Empty(t, itineraries, "it should retire the previous version when the file is parsed")
+	_, err = store.GetResponse("response.xml")
+	assert.Error(t, err)
+}
+
+func TestService_GetProgress(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := parser.SpawnWorkers(1)
+	defer pool.Flush()
+	s := New(ctx, []string{directory}, time.Hour, false, store, pool)
+
+	writeResponse(t, filepath.Join(directory, "first.xml"), 1)
+	writeResponse(t, filepath.Join(directory, "second.xml"), 2)
+	assert.False(t, s.GetProgress().Ready, "it should not be ready before the first scan")
+
+	s.Start()
+	defer s.Stop()
+	assert.Eventually(t, func() bool {
+		return s.GetProgress().Ready
+	}, time.Second, 10*time.Millisecond, "it should be ready when files of the first scan are parsed")
+	assert.Equal(t, Progress{
+		Ready:            true,
+		FilesCount:       2,
+		IngestedCount:    2,
+		ItinerariesCount: 3,
+	}, s.GetProgress())
+}
+
+func TestService_Restore(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	assert.NoError(t, err)
+	defer os.RemoveAll(directory)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := storage.NewMemoryStorage(ctx, currency.New(ctx, currency.NewStaticProvider(currency.Rates{Base: "SGD"})), scoring.NewWeighted(scoring.DefaultWeights))
+	pool := parser.SpawnWorkers(1)
+	defer pool.Flush()
+
+	writeResponse(t, filepath.Join(directory, "response.xml"), 2)
+	first := New(ctx, []string{directory}, time.Hour, false, store, pool)
+	first.Start()
+	first.Stop()
+	assert.Eventually(t, func() bool {
+		return first.GetProgress().Ready
+	}, time.Second, 10*time.Millisecond)
+
+	restarted := New(ctx, []string{directory}, time.Hour, false, store, pool)
+	restarted.Start()
+	defer restarted.Stop()
+	assert.Eventually(t, func() bool {
+		return restarted.GetProgress().Ready
+	}, time.Second, 10*time.Millisecond)
+	files := restarted.GetFiles()
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, FileStatusIngested, files[0].Status, "it should restore unchanged file from storage")
+		assert.Equal(t, 2, files[0].ItinerariesCount)
+		assert.True(t, files[0].IngestedAt.IsZero(), "it should not parse unchanged file again")
+	}
+}
@@ -0,0 +1,76 @@
+// Package airlines holds reference data of airlines bundled into the binary.
+package airlines
+
+import (
+	"bytes"
+	_ "embed" // airlines.csv
+	"encoding/csv"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed airlines.csv
+var airlinesCSV []byte
+
+type Airline struct {
+	Code    string
+	Name    string
+	Country string
+}
+
+var (
+	loadOnce sync.Once
+	airlines map[string]*Airline
+)
+
+func load() {
+	loadOnce.Do(func() {
+		var err error
+		airlines, err = parse(airlinesCSV)
+		if err != nil {
+			panic(err)
+		}
+	})
+}
+
+// Get returns airline by IATA code.
+func Get(code string) (*Airline, bool) {
+	load()
+
+	airline, ok := airlines[strings.ToUpper(code)]
+	return airline, ok
+}
+
+// GetAll returns all airlines ordered by code.
+func GetAll() []*Airline {
+	load()
+
+	result := make([]*Airline, 0, len(airlines))
+	for _, airline := range airlines {
+		result = append(result, airline)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Code < result[j].Code
+	})
+	return result
+}
+
+func parse(data []byte) (map[string]*Airline, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Airline, len(records))
+	// the first record is a header
+	for _, record := range records[1:] {
+		result[record[0]] = &Airline{
+			Code:    record[0],
+			Name:    record[1],
+			Country: record[2],
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,44 @@
+package airlines
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	result, err := parse(airlinesCSV)
+	assert.NoError(t, err, "it should parse bundled airlines")
+	assert.NotEmpty(t, result)
+}
+
+func TestGet(t *testing.T) {
+	items := map[string]struct {
+		code         string
+		expectedName string
+		expectedOk   bool
+	}{
+		"it should find EK": {
+			code:         "EK",
+			expectedName: "Emirates",
+			expectedOk:   true,
+		},
+		"it should find lowercase qr": {
+			code:         "qr",
+			expectedName: "Qatar Airways",
+			expectedOk:   true,
+		},
+		"it should not find unknown airline": {
+			code:       "ZZ",
+			expectedOk: false,
+		},
+	}
+
+	for message, item := range items {
+		airline, ok := Get(item.code)
+		assert.Equal(t, item.expectedOk, ok, message)
+		if ok {
+			assert.Equal(t, item.expectedName, airline.Name, message)
+		}
+	}
+}
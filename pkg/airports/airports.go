@@ -0,0 +1,234 @@
+// Package airports holds reference data of airports bundled into the binary.
+package airports
+
+import (
+	"bytes"
+	_ "embed" // airports.csv
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // timezones don't depend on the host
+	"unicode"
+)
+
+//go:embed airports.csv
+var airportsCSV []byte
+
+type Airport struct {
+	Code     string
+	TimeZone string
+	Location *time.Location `json:"-"`
+	Name     string
+	// CityCode is IATA code of the city or metropolitan area, e.g. LON for
+	// all London airports. It's the airport code for single airport cities.
+	CityCode  string
+	City      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+}
+
+var (
+	loadOnce sync.Once
+	airports map[string]*Airport
+	// cities keeps airports of the city code ordered by airport code.
+	cities map[string][]*Airport
+)
+
+func load() {
+	loadOnce.Do(func() {
+		var err error
+		airports, err = parse(airportsCSV)
+		if err != nil {
+			panic(err)
+		}
+		cities = groupByCity(airports)
+	})
+}
+
+// Get returns airport by IATA code.
+func Get(code string) (*Airport, bool) {
+	load()
+
+	airport, ok := airports[strings.ToUpper(code)]
+	return airport, ok
+}
+
+// GetAll returns all airports ordered by code.
+func GetAll() []*Airport {
+	load()
+
+	result := make([]*Airport, 0, len(airports))
+	for _, airport := range airports {
+		result = append(result, airport)
+	}
+	sortByCode(result)
+	return result
+}
+
+// minNameLength is the shortest query searched in names, shorter ones
+// are codes.
+const minNameLength = 4
+
+// IsCode tells whether query looks like IATA code: three latin letters.
+func IsCode(query string) bool {
+	if len(query) != 3 {
+		return false
+	}
+	for _, r := range query {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve returns airports matching the query, case-insensitive:
+// all airports of a city code, the airport of an airport code,
+// all airports of a city name or airports whose name has words starting
+// with the query. Unknown codes and queries shorter than minNameLength
+// aren't searched in names, nothing is returned for them.
+func Resolve(query string) []*Airport {
+	load()
+
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil
+	}
+
+	code := strings.ToUpper(query)
+	if city, ok := cities[code]; ok {
+		return city
+	}
+	if airport, ok := airports[code]; ok {
+		return []*Airport{airport}
+	}
+	if IsCode(query) || len([]rune(query)) < minNameLength {
+		return nil
+	}
+
+	words := splitWords(query)
+	var byCity, byName []*Airport
+	for _, airport := range airports {
+		if strings.EqualFold(airport.City, query) {
+			byCity = append(byCity, airport)
+		}
+		if hasWords(splitWords(airport.Name), words) {
+			byName = append(byName, airport)
+		}
+	}
+	if len(byCity) > 0 {
+		sortByCode(byCity)
+		return byCity
+	}
+	sortByCode(byName)
+	return byName
+}
+
+// splitWords returns lower case words of letters and digits.
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// hasWords tells whether name has the words in a row, the last one may
+// be a prefix of the name word, e.g. "heathrow" and "london heath".
+func hasWords(name, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	last := len(words) - 1
+	for start := 0; start+last < len(name); start++ {
+		matched := true
+		for k := 0; k < last && matched; k++ {
+			matched = name[start+k] == words[k]
+		}
+		if matched && strings.HasPrefix(name[start+last], words[last]) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetCodes returns codes of the airports.
+func GetCodes(list []*Airport) []string {
+	result := make([]string, 0, len(list))
+	for _, airport := range list {
+		result = append(result, airport.Code)
+	}
+	return result
+}
+
+// GetLocation returns timezone of the airport, UTC for unknown airports.
+func GetLocation(code string) *time.Location {
+	airport, ok := Get(code)
+	if !ok {
+		return time.UTC
+	}
+	return airport.Location
+}
+
+func parse(data []byte) (map[string]*Airport, error) {
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Airport, len(records))
+	// the first record is a header
+	for _, record := range records[1:] {
+		location, loadErr := time.LoadLocation(record[1])
+		if loadErr != nil {
+			return nil, fmt.Errorf("airport %s: %w", record[0], loadErr)
+		}
+		latitude, parseErr := strconv.ParseFloat(record[6], 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("airport %s: %w", record[0], parseErr)
+		}
+		longitude, parseErr := strconv.ParseFloat(record[7], 64)
+		if parseErr != nil {
+			return nil, fmt.Errorf("airport %s: %w", record[0], parseErr)
+		}
+		result[record[0]] = &Airport{
+			Code:      record[0],
+			TimeZone:  record[1],
+			Location:  location,
+			Name:      record[2],
+			CityCode:  record[3],
+			City:      record[4],
+			Country:   record[5],
+			Latitude:  latitude,
+			Longitude: longitude,
+		}
+	}
+
+	return result, nil
+}
+
+// groupByCity collects airports of metropolitan areas, city codes that are
+// also airport codes (e.g. BKK) resolve to all airports of the city.
+func groupByCity(byCode map[string]*Airport) map[string][]*Airport {
+	result := map[string][]*Airport{}
+	for _, airport := range byCode {
+		result[airport.CityCode] = append(result[airport.CityCode], airport)
+	}
+	for code, city := range result {
+		if len(city) == 1 && city[0].Code == code {
+			delete(result, code)
+			continue
+		}
+		sortByCode(city)
+	}
+	return result
+}
+
+func sortByCode(list []*Airport) {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Code < list[j].Code
+	})
+}
@@ -0,0 +1,91 @@
+package airports
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	result, err := parse(airportsCSV)
+	assert.NoError(t, err, "it should parse bundled airports")
+	assert.NotEmpty(t, result)
+}
+
+func TestGetLocation(t *testing.T) {
+	items := map[string]struct {
+		code           string
+		expectedOffset int
+	}{
+		"it should be +4 for DXB": {
+			code:           "DXB",
+			expectedOffset: 4 * 60 * 60,
+		},
+		"it should be +5:30 for lowercase del": {
+			code:           "del",
+			expectedOffset: 5*60*60 + 30*60,
+		},
+		"it should be UTC for unknown airport": {
+			code:           "ZZZ",
+			expectedOffset: 0,
+		},
+	}
+
+	for message, item := range items {
+		_, offset := time.Date(2018, 10, 27, 0, 0, 0, 0, GetLocation(item.code)).Zone()
+		assert.Equal(t, item.expectedOffset, offset, message)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	items := map[string]struct {
+		query    string
+		expected []string
+	}{
+		"it should resolve city code to all airports of the city": {
+			query:    "LON",
+			expected: []string{"LCY", "LGW", "LHR", "LTN", "STN"},
+		},
+		"it should resolve city code equal to airport code to all airports of the city": {
+			query:    "bkk",
+			expected: []string{"BKK", "DMK"},
+		},
+		"it should resolve airport code to the airport": {
+			query:    "dmk",
+			expected: []string{"DMK"},
+		},
+		"it should resolve city name to all airports of the city": {
+			query:    "moscow",
+			expected: []string{"DME", "SVO", "VKO"},
+		},
+		"it should resolve part of airport name": {
+			query:    "heathrow",
+			expected: []string{"LHR"},
+		},
+		"it should resolve words of airport name": {
+			query:    "london heath",
+			expected: []string{"LHR"},
+		},
+		"it should resolve nothing for unknown query": {
+			query:    "ZZZ",
+			expected: []string{},
+		},
+		"it should not search unknown code in names": {
+			query:    "nat",
+			expected: []string{},
+		},
+		"it should not search short query in names": {
+			query:    "in",
+			expected: []string{},
+		},
+		"it should not match the middle of a word": {
+			query:    "ternational",
+			expected: []string{},
+		},
+	}
+
+	for message, item := range items {
+		assert.Equal(t, item.expected, GetCodes(Resolve(item.query)), message)
+	}
+}
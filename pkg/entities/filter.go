@@ -0,0 +1,206 @@
+package entities
+
+import (
+	"strings"
+	"time"
+)
+
+// ItineraryFilter narrows down itineraries of a city pair.
+// Zero value fields are not applied. Itineraries without fares
+// for every requested passenger are excluded.
+type ItineraryFilter struct {
+	TripType   string
+	ReturnDate time.Time
+	Passengers Passengers
+	Layovers   LayoverFilter
+	Flights    FlightFilter
+	Schedule   ScheduleFilter
+}
+
+// LayoverFilter is applied to stops and layovers of each direction.
+type LayoverFilter struct {
+	// MaxStops is unset when nil, zero means direct flights only.
+	MaxStops                   *int
+	ConnectionAirports         []string
+	ExcludedConnectionAirports []string
+	MinDuration                time.Duration
+	MaxDuration                time.Duration
+	ExcludeOvernight           bool
+	SameAirportOnly            bool
+}
+
+// FlightFilter is applied to every flight of both directions.
+type FlightFilter struct {
+	Carriers          []string
+	ExcludedCarriers  []string
+	Classes           []string
+	ETicketOnly       bool
+	SingleCarrierOnly bool
+}
+
+func (f *ItineraryFilter) IsEmpty() bool {
+	return f == nil || (f.TripType == "" &&
+		f.ReturnDate.IsZero() &&
+		f.Passengers.IsDefault() &&
+		f.Layovers.IsEmpty() &&
+		f.Flights.IsEmpty() &&
+		f.Schedule.IsEmpty())
+}
+
+func (f *LayoverFilter) IsEmpty() bool {
+	return f.MaxStops == nil &&
+		len(f.ConnectionAirports) == 0 &&
+		len(f.ExcludedConnectionAirports) == 0 &&
+		f.MinDuration == 0 &&
+		f.MaxDuration == 0 &&
+		!f.ExcludeOvernight &&
+		!f.SameAirportOnly
+}
+
+func (f *ItineraryFilter) GetPassengers() Passengers {
+	if f == nil {
+		return Passengers{}.OrDefault()
+	}
+	return f.Passengers.OrDefault()
+}
+
+func (f *ItineraryFilter) Match(itinerary *Itinerary) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	if !itinerary.CanPrice(f.GetPassengers()) {
+		return false
+	}
+
+	if f.TripType != "" && itinerary.GetTripType() != f.TripType {
+		return false
+	}
+
+	if !f.ReturnDate.IsZero() {
+		if len(itinerary.Return) == 0 || !IsSameDate(itinerary.Return[0].DepartureTimeStamp.Time, f.ReturnDate) {
+			return false
+		}
+	}
+
+	return f.Layovers.Match(itinerary) &&
+		f.Flights.Match(itinerary) &&
+		f.Schedule.Match(itinerary)
+}
+
+func (f *FlightFilter) IsEmpty() bool {
+	return len(f.Carriers) == 0 &&
+		len(f.ExcludedCarriers) == 0 &&
+		len(f.Classes) == 0 &&
+		!f.ETicketOnly &&
+		!f.SingleCarrierOnly
+}
+
+func (f *FlightFilter) Match(itinerary *Itinerary) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	var carrier string
+	for _, flights := range [][]Flight{itinerary.Onward, itinerary.Return} {
+		for k := range flights {
+			if !f.matchFlight(&flights[k]) {
+				return false
+			}
+
+			if f.SingleCarrierOnly {
+				if carrier != "" && carrier != flights[k].Carrier.Code {
+					return false
+				}
+				carrier = flights[k].Carrier.Code
+			}
+		}
+	}
+
+	return true
+}
+
+func (f *FlightFilter) matchFlight(flight *Flight) bool {
+	if len(f.Carriers) > 0 && !containsCode(f.Carriers, flight.Carrier.Code) {
+		return false
+	}
+
+	if containsCode(f.ExcludedCarriers, flight.Carrier.Code) {
+		return false
+	}
+
+	if len(f.Classes) > 0 && !containsCode(f.Classes, flight.Class) {
+		return false
+	}
+
+	if f.ETicketOnly && flight.TicketType != TicketTypeElectronic {
+		return false
+	}
+
+	return true
+}
+
+func (f *LayoverFilter) Match(itinerary *Itinerary) bool {
+	if f.IsEmpty() {
+		return true
+	}
+
+	if f.MaxStops != nil && (itinerary.GetOnwardStops() > *f.MaxStops || itinerary.GetReturnStops() > *f.MaxStops) {
+		return false
+	}
+
+	layovers := itinerary.GetLayovers()
+	for k := range layovers {
+		if !f.matchLayover(&layovers[k]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (f *LayoverFilter) matchLayover(layover *Layover) bool {
+	if len(f.ConnectionAirports) > 0 &&
+		(!containsCode(f.ConnectionAirports, layover.ArrivalAirport) || !containsCode(f.ConnectionAirports, layover.DepartureAirport)) {
+		return false
+	}
+
+	if containsCode(f.ExcludedConnectionAirports, layover.ArrivalAirport) ||
+		containsCode(f.ExcludedConnectionAirports, layover.DepartureAirport) {
+		return false
+	}
+
+	if f.MinDuration != 0 && layover.Duration < f.MinDuration {
+		return false
+	}
+
+	if f.MaxDuration != 0 && layover.Duration > f.MaxDuration {
+		return false
+	}
+
+	if f.ExcludeOvernight && layover.IsOvernight() {
+		return false
+	}
+
+	if f.SameAirportOnly && !layover.IsSameAirport() {
+		return false
+	}
+
+	return true
+}
+
+func containsCode(codes []string, code string) bool {
+	for _, c := range codes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSameDate tells whether times are of the same date, each in its own location.
+func IsSameDate(t1, t2 time.Time) bool {
+	year1, month1, day1 := t1.Date()
+	year2, month2, day2 := t2.Date()
+	return year1 == year2 && month1 == month2 && day1 == day2
+}
@@ -0,0 +1,144 @@
+package entities
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFlight(source, destination string, departure, arrival time.Time) Flight {
+	return Flight{
+		Source:             source,
+		Destination:        destination,
+		DepartureTimeStamp: FlightDate{departure}.In(source),
+		ArrivalTimeStamp:   FlightDate{arrival}.In(destination),
+		NumberOfStops:      "0",
+	}
+}
+
+func TestLayoverFilter_Match(t *testing.T) {
+	viaDelhi := &Itinerary{
+		Onward: []Flight{
+			newFlight("DXB", "DEL", time.Date(2018, 10, 22, 0, 5, 0, 0, time.UTC), time.Date(2018, 10, 22, 4, 45, 0, 0, time.UTC)),
+			newFlight("DEL", "BKK", time.Date(2018, 10, 22, 13, 50, 0, 0, time.UTC), time.Date(2018, 10, 22, 19, 35, 0, 0, time.UTC)),
+		},
+	}
+	viaDubaiOvernight := &Itinerary{
+		Onward: []Flight{
+			newFlight("MCT", "DWC", time.Date(2018, 10, 22, 20, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 21, 10, 0, 0, time.UTC)),
+			newFlight("DXB", "BKK", time.Date(2018, 10, 23, 3, 0, 0, 0, time.UTC), time.Date(2018, 10, 23, 12, 30, 0, 0, time.UTC)),
+		},
+	}
+	direct := &Itinerary{
+		Onward: []Flight{
+			newFlight("DXB", "BKK", time.Date(2018, 10, 22, 9, 5, 0, 0, time.UTC), time.Date(2018, 10, 22, 18, 5, 0, 0, time.UTC)),
+		},
+	}
+	zero := 0
+
+	items := map[string]struct {
+		filter   LayoverFilter
+		expected []bool
+	}{
+		"it should keep direct flights only": {
+			filter:   LayoverFilter{MaxStops: &zero},
+			expected: []bool{false, false, true},
+		},
+		"it should keep connections in DEL only": {
+			filter:   LayoverFilter{ConnectionAirports: []string{"DEL"}},
+			expected: []bool{true, false, true},
+		},
+		"it should exclude connections in DEL": {
+			filter:   LayoverFilter{ExcludedConnectionAirports: []string{"del"}},
+			expected: []bool{false, true, true},
+		},
+		"it should keep layovers of at least 6 hours": {
+			filter:   LayoverFilter{MinDuration: 6 * time.Hour},
+			expected: []bool{true, false, true},
+		},
+		"it should keep layovers of at most 6 hours": {
+			filter:   LayoverFilter{MaxDuration: 6 * time.Hour},
+			expected: []bool{false, true, true},
+		},
+		"it should exclude overnight layovers": {
+			filter:   LayoverFilter{ExcludeOvernight: true},
+			expected: []bool{true, false, true},
+		},
+		"it should exclude airport change": {
+			filter:   LayoverFilter{SameAirportOnly: true},
+			expected: []bool{true, false, true},
+		},
+	}
+
+	for message, item := range items {
+		for k, itinerary := range []*Itinerary{viaDelhi, viaDubaiOvernight, direct} {
+			assert.Equal(t, item.expected[k], item.filter.Match(itinerary), message)
+		}
+	}
+}
+
+func TestFlightFilter_Match(t *testing.T) {
+	itinerary := &Itinerary{}
+	_ = xml.Unmarshal([]byte(`
+<Flights>
+	<OnwardPricedItinerary>
+		<Flights>
+			<Flight>
+				<Carrier id="AI">AirIndia</Carrier>
+				<Source>DXB</Source>
+				<Destination>DEL</Destination>
+				<Class>G</Class>
+				<TicketType>E</TicketType>
+			</Flight>
+			<Flight>
+				<Carrier id="9W">JetAirways</Carrier>
+				<Source>DEL</Source>
+				<Destination>BKK</Destination>
+				<Class>U</Class>
+				<TicketType>E</TicketType>
+			</Flight>
+		</Flights>
+	</OnwardPricedItinerary>
+</Flights>`), itinerary)
+
+	items := map[string]struct {
+		filter   FlightFilter
+		expected bool
+	}{
+		"it should match AI and 9W carriers": {
+			filter:   FlightFilter{Carriers: []string{"AI", "9W"}},
+			expected: true,
+		},
+		"it should not match AI carrier only": {
+			filter:   FlightFilter{Carriers: []string{"AI"}},
+			expected: false,
+		},
+		"it should not match excluded 9W carrier": {
+			filter:   FlightFilter{ExcludedCarriers: []string{"9W"}},
+			expected: false,
+		},
+		"it should match G and U classes": {
+			filter:   FlightFilter{Classes: []string{"G", "U", "Y"}},
+			expected: true,
+		},
+		"it should not match G class only": {
+			filter:   FlightFilter{Classes: []string{"G"}},
+			expected: false,
+		},
+		"it should match electronic tickets": {
+			filter:   FlightFilter{ETicketOnly: true},
+			expected: true,
+		},
+		"it should not match several carriers": {
+			filter:   FlightFilter{SingleCarrierOnly: true},
+			expected: false,
+		},
+	}
+
+	assert.Equal(t, Carrier{Code: "AI", Name: "AirIndia"}, itinerary.Onward[0].Carrier, "it should keep carrier code")
+	for message, item := range items {
+		assert.Equal(t, item.expected, item.filter.Match(itinerary), message)
+	}
+}
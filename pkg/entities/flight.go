@@ -1,15 +1,22 @@
 package entities
 
 import (
+	"aviasales/pkg/airlines"
+	"aviasales/pkg/airports"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"strings"
 	"time"
 
+	uuid "github.com/satori/go.uuid"
 	"github.com/shopspring/decimal"
 )
 
 type SourceCity string
 type DestinationCity string
 type ItineraryUUID string
+type ResponseID string
 
 type Itineraries struct {
 	Itineraries   []*Itinerary
@@ -20,20 +27,51 @@ type Itineraries struct {
 	Optimal       *Itinerary
 }
 
+// SearchResponse describes one partner search response (AirFareSearchResponse)
+// the itineraries were loaded from.
 type SearchResponse struct {
-	RequestID   string      `xml:"RequestId"`
-	Itineraries []Itinerary `xml:"PricedItineraries>Flights"`
+	ID       ResponseID
+	FileName string
+	// FileSize and FileModifiedAt identify the parsed version of the file.
+	FileSize         int64
+	FileModifiedAt   time.Time
+	RequestID        string       `xml:"RequestId"`
+	RequestTime      ResponseDate `xml:"RequestTime,attr"`
+	ResponseTime     ResponseDate `xml:"ResponseTime,attr"`
+	ItinerariesCount int
+	DuplicatesCount  int
+	ParseErrors      []ParseError
+	// ParseWarnings are of stored itineraries, e.g. airport changes.
+	ParseWarnings []ParseError `json:",omitempty"`
+	// Strict response is rejected as a whole on the first parse error.
+	Strict   bool
+	Rejected bool
+}
+
+type ResponseDate struct {
+	time.Time
 }
 
 type Itinerary struct {
-	UUID    ItineraryUUID
-	Onward  []Flight `xml:"OnwardPricedItinerary>Flights>Flight"`
-	Return  []Flight `xml:"ReturnPricedItinerary>Flights>Flight"`
-	Pricing *Price   `xml:"Pricing"`
+	UUID       ItineraryUUID
+	ResponseID ResponseID
+	Onward     []Flight `xml:"OnwardPricedItinerary>Flights>Flight"`
+	Return     []Flight `xml:"ReturnPricedItinerary>Flights>Flight"`
+	Pricing    *Price   `xml:"Pricing"`
+	// PartyPrice is set on search results, it depends on requested passengers.
+	PartyPrice *PartyPrice `xml:"-" json:",omitempty"`
+	// ScoreBreakdown is set on ranked search results.
+	ScoreBreakdown *ScoreBreakdown `xml:"-" json:",omitempty"`
+	// BaseRate converts prices to the base currency, so prices in different
+	// currencies can be compared. Zero means prices are in the base currency.
+	BaseRate decimal.Decimal `xml:"-" json:"-"`
+	// NoBaseRate is set when there is no rate of the currency, such
+	// itineraries are left out of rankings comparing prices.
+	NoBaseRate bool `xml:"-" json:"-"`
 }
 
 type Flight struct {
-	Carrier            string
+	Carrier            Carrier
 	FlightNumber       string
 	Source             string
 	Destination        string
@@ -41,7 +79,17 @@ type Flight struct {
 	ArrivalTimeStamp   FlightDate
 	Class              string
 	NumberOfStops      string
+	FareBasis          string
 	TicketType         string
+	// SourceName and DestinationName are set on search results.
+	SourceName      string `xml:"-" json:",omitempty"`
+	DestinationName string `xml:"-" json:",omitempty"`
+}
+
+// Carrier is an airline, e.g. <Carrier id="AI">AirIndia</Carrier>.
+type Carrier struct {
+	Code string `xml:"id,attr"`
+	Name string `xml:",chardata"`
 }
 
 type FlightDate struct {
@@ -59,6 +107,26 @@ type Charge struct {
 	Cost       decimal.Decimal `xml:",chardata"`
 }
 
+// itineraryNamespace is used to derive name based (v5) itinerary UUIDs.
+var itineraryNamespace = uuid.Must(uuid.FromString("5c0f9e2a-8a4b-4f6e-9d1c-3e7b2a6f4d10"))
+
+const (
+	DirectionOnward = "O"
+	DirectionReturn = "R"
+)
+
+const TicketTypeElectronic = "E"
+
+const (
+	TripTypeOneWay    = "oneWay"
+	TripTypeRoundTrip = "roundTrip"
+)
+
+const (
+	flightDateLayout   = "2006-01-02T1504"
+	responseDateLayout = "02-01-2006 15:04:05"
+)
+
 const (
 	ChargeTypeBaseFare     = "BaseFare"
 	ChargeTypeAirlineTaxes = "AirlineTaxes"
@@ -71,17 +139,118 @@ const (
 	TypeSingleInfant = "SingleInfant"
 )
 
+// In interprets wall clock time of the date in the airport timezone.
+func (c FlightDate) In(airportCode string) FlightDate {
+	if c.IsZero() {
+		return c
+	}
+	year, month, day := c.Date()
+	hour, minute, sec := c.Clock()
+	return FlightDate{time.Date(year, month, day, hour, minute, sec, c.Nanosecond(), airports.GetLocation(airportCode))}
+}
+
+// UnmarshalXML parses local airport time, its timezone
+// is set by Flight.UnmarshalXML.
 func (c *FlightDate) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	var v string
-	_ = d.DecodeElement(&v, &start)
-	parse, err := time.Parse("2006-01-02T1504", v)
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	parse, err := time.Parse(flightDateLayout, strings.TrimSpace(v))
 	if err != nil {
-		return nil
+		return fmt.Errorf("%s: %w", start.Name.Local, err)
 	}
 	*c = FlightDate{parse}
 	return nil
 }
 
+func (f *Flight) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type flight Flight
+	var v flight
+	err := d.DecodeElement(&v, &start)
+	if err != nil {
+		return err
+	}
+	v.FareBasis = strings.TrimSpace(v.FareBasis)
+	v.DepartureTimeStamp = v.DepartureTimeStamp.In(v.Source)
+	v.ArrivalTimeStamp = v.ArrivalTimeStamp.In(v.Destination)
+	*f = Flight(v)
+	return nil
+}
+
+// MarshalJSON adds UTC departure and arrival times to local ones.
+func (f Flight) MarshalJSON() ([]byte, error) {
+	type flight Flight
+	return json.Marshal(struct {
+		flight
+		DepartureTimeStampUTC time.Time
+		ArrivalTimeStampUTC   time.Time
+		Duration              int64
+	}{
+		flight:                flight(f),
+		DepartureTimeStampUTC: f.DepartureTimeStamp.UTC(),
+		ArrivalTimeStampUTC:   f.ArrivalTimeStamp.UTC(),
+		Duration:              int64(f.ArrivalTimeStamp.Sub(f.DepartureTimeStamp.Time)),
+	})
+}
+
+// Describe sets names of airports and of the carrier from reference data,
+// the partner name is kept for unknown carriers.
+func (f *Flight) Describe() {
+	if airport, ok := airports.Get(f.Source); ok {
+		f.SourceName = airport.Name
+	}
+	if airport, ok := airports.Get(f.Destination); ok {
+		f.DestinationName = airport.Name
+	}
+	if airline, ok := airlines.Get(f.Carrier.Code); ok {
+		f.Carrier.Name = airline.Name
+	}
+}
+
+// Describe sets reference names on copies of the flights, so stored
+// itineraries sharing them don't change.
+func (i *Itinerary) Describe() {
+	for _, flights := range []*[]Flight{&i.Onward, &i.Return} {
+		if *flights == nil {
+			continue
+		}
+		described := make([]Flight, len(*flights))
+		copy(described, *flights)
+		for k := range described {
+			described[k].Describe()
+		}
+		*flights = described
+	}
+}
+
+func (c *ResponseDate) UnmarshalXMLAttr(attr xml.Attr) error {
+	parse, err := time.Parse(responseDateLayout, attr.Value)
+	if err != nil {
+		return err
+	}
+	*c = ResponseDate{parse}
+	return nil
+}
+
+// SetAttributes fills response fields stored as attributes
+// of the AirFareSearchResponse element.
+func (r *SearchResponse) SetAttributes(attrs []xml.Attr) error {
+	for _, attr := range attrs {
+		var err error
+		switch attr.Name.Local {
+		case "RequestTime":
+			err = r.RequestTime.UnmarshalXMLAttr(attr)
+		case "ResponseTime":
+			err = r.ResponseTime.UnmarshalXMLAttr(attr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (i *Itinerary) GetPrice(chargeType, rateType string) decimal.Decimal {
 	if i.Pricing != nil {
 		for s := range i.Pricing.ServiceCharges {
@@ -94,36 +263,121 @@ func (i *Itinerary) GetPrice(chargeType, rateType string) decimal.Decimal {
 	return decimal.Zero
 }
 
+// GetFlightsKey identifies the set of flights of itinerary
+// regardless of its pricing: direction, carrier, flight number and
+// departure date of every onward and return flight.
+func (i *Itinerary) GetFlightsKey() string {
+	return i.getFlightsKey(false)
+}
+
+// GetContentUUID derives itinerary UUID from its flights key with fare basis
+// of every flight added.
+// The same offer always gets the same UUID, whichever response it came from.
+func (i *Itinerary) GetContentUUID() ItineraryUUID {
+	return ItineraryUUID(uuid.NewV5(itineraryNamespace, i.getFlightsKey(true)).String())
+}
+
+func (i *Itinerary) getFlightsKey(withFareBasis bool) string {
+	parts := make([]string, 0, len(i.Onward)+len(i.Return))
+	for _, direction := range []struct {
+		name    string
+		flights []Flight
+	}{
+		{DirectionOnward, i.Onward},
+		{DirectionReturn, i.Return},
+	} {
+		for k := range direction.flights {
+			part := fmt.Sprintf("%s_%s_%s_%s",
+				direction.name,
+				direction.flights[k].Carrier.Code,
+				direction.flights[k].FlightNumber,
+				direction.flights[k].DepartureTimeStamp.Format(flightDateLayout),
+			)
+			if withFareBasis {
+				part += "_" + direction.flights[k].FareBasis
+			}
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "|")
+}
+
+func (i *Itinerary) GetTripType() string {
+	if len(i.Return) == 0 {
+		return TripTypeOneWay
+	}
+	return TripTypeRoundTrip
+}
+
+// GetDuration returns travel time of both directions.
 func (i *Itinerary) GetDuration() int64 {
-	if len(i.Onward) == 0 {
+	return i.GetOnwardDuration() + i.GetReturnDuration()
+}
+
+func (i *Itinerary) GetOnwardDuration() int64 {
+	return getDuration(i.Onward)
+}
+
+func (i *Itinerary) GetReturnDuration() int64 {
+	return getDuration(i.Return)
+}
+
+func (i *Itinerary) GetDurationWithoutTransfer() int64 {
+	return getDurationWithoutTransfer(i.Onward) + getDurationWithoutTransfer(i.Return)
+}
+
+func (i *Itinerary) GetTransferDuration() int64 {
+	return getTransferDuration(i.Onward) + getTransferDuration(i.Return)
+}
+
+func getDuration(flights []Flight) int64 {
+	if len(flights) == 0 {
 		return 0
 	}
-	departureTime, arrivalTime := i.Onward[0].DepartureTimeStamp.Time, i.Onward[len(i.Onward)-1].ArrivalTimeStamp.Time
+	departureTime, arrivalTime := flights[0].DepartureTimeStamp.Time, flights[len(flights)-1].ArrivalTimeStamp.Time
 	return int64(arrivalTime.Sub(departureTime))
 }
 
-func (i *Itinerary) GetDurationWithoutTransfer() int64 {
-	if len(i.Onward) == 0 {
+func getDurationWithoutTransfer(flights []Flight) int64 {
+	if len(flights) == 0 {
 		return 0
 	}
 
 	var result int64
-	for k := range i.Onward {
-		departureTime, arrivalTime := i.Onward[k].DepartureTimeStamp.Time, i.Onward[k].ArrivalTimeStamp.Time
+	for k := range flights {
+		departureTime, arrivalTime := flights[k].DepartureTimeStamp.Time, flights[k].ArrivalTimeStamp.Time
 		result += int64(arrivalTime.Sub(departureTime))
 	}
 	return result
 }
 
-func (i *Itinerary) GetTransferDuration() int64 {
-	if len(i.Onward) == 0 {
+func getTransferDuration(flights []Flight) int64 {
+	if len(flights) == 0 {
 		return 0
 	}
 
 	var result int64
-	for k := 0; k < len(i.Onward)-1; k++ {
-		departureTime, arrivalTime := i.Onward[k+1].DepartureTimeStamp.Time, i.Onward[k].ArrivalTimeStamp.Time
+	for k := 0; k < len(flights)-1; k++ {
+		departureTime, arrivalTime := flights[k+1].DepartureTimeStamp.Time, flights[k].ArrivalTimeStamp.Time
 		result += int64(departureTime.Sub(arrivalTime))
 	}
 	return result
 }
+
+// MarshalJSON adds trip type and per direction durations to itinerary.
+func (i Itinerary) MarshalJSON() ([]byte, error) {
+	type itinerary Itinerary
+	return json.Marshal(struct {
+		itinerary
+		TripType       string
+		OnwardDuration int64
+		ReturnDuration int64
+		Duration       int64
+	}{
+		itinerary:      itinerary(i),
+		TripType:       i.GetTripType(),
+		OnwardDuration: i.GetOnwardDuration(),
+		ReturnDuration: i.GetReturnDuration(),
+		Duration:       i.GetDuration(),
+	})
+}
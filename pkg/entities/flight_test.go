@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -13,7 +14,7 @@ func TestItinerary_GetDuration(t *testing.T) {
 		xml           string
 		expectedValue int64
 	}{
-		"it should be 16 hours with 2 flight": {
+		"it should be 13 hours with 2 flight": {
 			xml: `
 <Flights>
 	<OnwardPricedItinerary>
@@ -54,7 +55,7 @@ func TestItinerary_GetDuration(t *testing.T) {
 		<ServiceCharges type="SingleAdult" ChargeType="BaseFare">233.00</ServiceCharges>
 	</Pricing>
 </Flights>`,
-			expectedValue: int64(16 * time.Hour),
+			expectedValue: int64(13 * time.Hour),
 		},
 		"it should be 1 hour with 1 light": {
 			xml: `
@@ -67,7 +68,7 @@ func TestItinerary_GetDuration(t *testing.T) {
 				<Source>DXB</Source>
 				<Destination>CAN</Destination>
 				<DepartureTimeStamp>2018-10-28T0100</DepartureTimeStamp>
-				<ArrivalTimeStamp>2018-10-28T0200</ArrivalTimeStamp>
+				<ArrivalTimeStamp>2018-10-28T0600</ArrivalTimeStamp>
 				<Class>T</Class>
 				<NumberOfStops>0</NumberOfStops>
 				<FareBasis>
@@ -111,7 +112,7 @@ func TestItinerary_GetDurationWithoutTransfer(t *testing.T) {
 		xml           string
 		expectedValue int64
 	}{
-		"it should be 12 hours with 2 flight": {
+		"it should be 9 hours with 2 flight": {
 			xml: `
 <Flights>
 	<OnwardPricedItinerary>
@@ -152,7 +153,7 @@ func TestItinerary_GetDurationWithoutTransfer(t *testing.T) {
 		<ServiceCharges type="SingleAdult" ChargeType="BaseFare">233.00</ServiceCharges>
 	</Pricing>
 </Flights>`,
-			expectedValue: int64(12 * time.Hour),
+			expectedValue: int64(9 * time.Hour),
 		},
 		"it should be 1 hour with 1 light": {
 			xml: `
@@ -165,7 +166,7 @@ func TestItinerary_GetDurationWithoutTransfer(t *testing.T) {
 				<Source>DXB</Source>
 				<Destination>CAN</Destination>
 				<DepartureTimeStamp>2018-10-28T0100</DepartureTimeStamp>
-				<ArrivalTimeStamp>2018-10-28T0200</ArrivalTimeStamp>
+				<ArrivalTimeStamp>2018-10-28T0600</ArrivalTimeStamp>
 				<Class>T</Class>
 				<NumberOfStops>0</NumberOfStops>
 				<FareBasis>
@@ -301,3 +302,170 @@ func TestItinerary_GetTransferDuration(t *testing.T) {
 		assert.Equal(t, item.expectedValue, itinerary.GetTransferDuration(), message)
 	}
 }
+
+func TestSearchResponse_SetAttributes(t *testing.T) {
+	items := map[string]struct {
+		attrs                []xml.Attr
+		expectedRequestTime  time.Time
+		expectedResponseTime time.Time
+		expectedError        bool
+	}{
+		"it should parse request and response time": {
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "RequestTime"}, Value: "28-09-2015 20:23:49"},
+				{Name: xml.Name{Local: "ResponseTime"}, Value: "28-09-2015 20:23:56"},
+			},
+			expectedRequestTime:  time.Date(2015, 9, 28, 20, 23, 49, 0, time.UTC),
+			expectedResponseTime: time.Date(2015, 9, 28, 20, 23, 56, 0, time.UTC),
+		},
+		"it should fail on malformed time": {
+			attrs: []xml.Attr{
+				{Name: xml.Name{Local: "RequestTime"}, Value: "2015-09-28T2023"},
+			},
+			expectedError: true,
+		},
+	}
+
+	for message, item := range items {
+		response := &SearchResponse{}
+		err := response.SetAttributes(item.attrs)
+		if item.expectedError {
+			assert.Error(t, err, message)
+			continue
+		}
+		assert.NoError(t, err, message)
+		assert.Equal(t, item.expectedRequestTime, response.RequestTime.Time, message)
+		assert.Equal(t, item.expectedResponseTime, response.ResponseTime.Time, message)
+	}
+}
+
+func TestItinerary_GetContentUUID(t *testing.T) {
+	flight := Flight{
+		Carrier:            Carrier{Code: "AI", Name: "AirIndia"},
+		FlightNumber:       "996",
+		Source:             "DXB",
+		Destination:        "DEL",
+		DepartureTimeStamp: FlightDate{Time: time.Date(2018, 10, 22, 0, 5, 0, 0, time.UTC)},
+		ArrivalTimeStamp:   FlightDate{Time: time.Date(2018, 10, 22, 4, 45, 0, 0, time.UTC)},
+		FareBasis:          "2820231f40c802-03e6-4655-9ece-0fb1ad670b5c",
+	}
+	otherFareBasis := flight
+	otherFareBasis.FareBasis = "2820303decf751-5511-447a-aeb1-810a6b10ad7d"
+
+	base := &Itinerary{Onward: []Flight{flight}}
+
+	items := map[string]struct {
+		itinerary     *Itinerary
+		expectedEqual bool
+	}{
+		"it should be equal for the same flights": {
+			itinerary:     &Itinerary{Onward: []Flight{flight}, ResponseID: "RS_ViaOW.xml"},
+			expectedEqual: true,
+		},
+		"it should differ for another fare basis": {
+			itinerary:     &Itinerary{Onward: []Flight{otherFareBasis}},
+			expectedEqual: false,
+		},
+		"it should differ for another direction": {
+			itinerary:     &Itinerary{Onward: []Flight{}, Return: []Flight{flight}},
+			expectedEqual: false,
+		},
+	}
+
+	for message, item := range items {
+		assert.Equal(t, item.expectedEqual, base.GetContentUUID() == item.itinerary.GetContentUUID(), message)
+	}
+	expected := ItineraryUUID(uuid.NewV5(itineraryNamespace, base.GetFlightsKey()+"_"+flight.FareBasis).String())
+	assert.Equal(t, expected, base.GetContentUUID(), "it should derive UUID from flights key with fare basis")
+}
+
+func TestItinerary_GetDuration_RoundTrip(t *testing.T) {
+	itinerary := &Itinerary{}
+	_ = xml.Unmarshal([]byte(`
+<Flights>
+	<OnwardPricedItinerary>
+		<Flights>
+			<Flight>
+				<Source>DXB</Source>
+				<Destination>DEL</Destination>
+				<DepartureTimeStamp>2018-10-22T0005</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-22T0445</ArrivalTimeStamp>
+			</Flight>
+			<Flight>
+				<Source>DEL</Source>
+				<Destination>BKK</Destination>
+				<DepartureTimeStamp>2018-10-22T1350</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-22T1935</ArrivalTimeStamp>
+			</Flight>
+		</Flights>
+	</OnwardPricedItinerary>
+	<ReturnPricedItinerary>
+		<Flights>
+			<Flight>
+				<Source>BKK</Source>
+				<Destination>DEL</Destination>
+				<DepartureTimeStamp>2018-10-30T0850</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-30T1205</ArrivalTimeStamp>
+			</Flight>
+			<Flight>
+				<Source>DEL</Source>
+				<Destination>DXB</Destination>
+				<DepartureTimeStamp>2018-10-30T2040</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-30T2300</ArrivalTimeStamp>
+			</Flight>
+		</Flights>
+	</ReturnPricedItinerary>
+</Flights>`), itinerary)
+
+	assert.Equal(t, TripTypeRoundTrip, itinerary.GetTripType())
+	assert.Equal(t, int64(16*time.Hour+30*time.Minute), itinerary.GetOnwardDuration())
+	assert.Equal(t, int64(17*time.Hour+10*time.Minute), itinerary.GetReturnDuration())
+	assert.Equal(t, int64(33*time.Hour+40*time.Minute), itinerary.GetDuration())
+	assert.Equal(t, int64(16*time.Hour), itinerary.GetDurationWithoutTransfer())
+	assert.Equal(t, int64(9*time.Hour+5*time.Minute+8*time.Hour+35*time.Minute), itinerary.GetTransferDuration())
+}
+
+func TestItinerary_Describe(t *testing.T) {
+	onward := []Flight{
+		{Carrier: Carrier{Code: "EK", Name: "Emirates Airline"}, Source: "DXB", Destination: "DMK"},
+		{Carrier: Carrier{Code: "ZZ", Name: "Partner Air"}, Source: "DMK", Destination: "ZZZ"},
+	}
+	itinerary := Itinerary{Onward: onward}
+
+	itinerary.Describe()
+
+	assert.Equal(t, "Dubai International Airport", itinerary.Onward[0].SourceName, "it should set source airport name")
+	assert.Equal(t, "Don Mueang International Airport", itinerary.Onward[0].DestinationName, "it should set destination airport name")
+	assert.Equal(t, "Emirates", itinerary.Onward[0].Carrier.Name, "it should set carrier name from reference data")
+	assert.Equal(t, "", itinerary.Onward[1].DestinationName, "it should skip unknown airport")
+	assert.Equal(t, "Partner Air", itinerary.Onward[1].Carrier.Name, "it should keep partner name of unknown carrier")
+	assert.Equal(t, "", onward[0].SourceName, "it should not change flights of the original itinerary")
+	assert.Nil(t, itinerary.Return, "it should keep one way itinerary without return flights")
+}
+
+func TestItinerary_GetFlightsKey(t *testing.T) {
+	onward := Flight{Carrier: Carrier{Code: "AI"}, FlightNumber: "996", DepartureTimeStamp: FlightDate{Time: time.Date(2018, 10, 22, 0, 5, 0, 0, time.UTC)}}
+	back := Flight{Carrier: Carrier{Code: "AI"}, FlightNumber: "332", DepartureTimeStamp: FlightDate{Time: time.Date(2018, 10, 30, 8, 50, 0, 0, time.UTC)}}
+	otherFareBasis := onward
+	otherFareBasis.FareBasis = "2820303decf751-5511-447a-aeb1-810a6b10ad7d"
+
+	base := &Itinerary{Onward: []Flight{onward}, Return: []Flight{back}}
+
+	items := map[string]struct {
+		itinerary     *Itinerary
+		expectedEqual bool
+	}{
+		"it should be equal for another fare basis": {
+			itinerary:     &Itinerary{Onward: []Flight{otherFareBasis}, Return: []Flight{back}},
+			expectedEqual: true,
+		},
+		"it should differ for the same flights in another direction": {
+			itinerary:     &Itinerary{Onward: []Flight{onward, back}},
+			expectedEqual: false,
+		},
+	}
+
+	for message, item := range items {
+		assert.Equal(t, item.expectedEqual, base.GetFlightsKey() == item.itinerary.GetFlightsKey(), message)
+	}
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+)
+
+// Layover is a connection between two consecutive flights of one direction.
+type Layover struct {
+	Direction        string
+	ArrivalAirport   string
+	DepartureAirport string
+	Arrival          time.Time
+	Departure        time.Time
+	Duration         time.Duration
+}
+
+// IsSameAirport reports whether passenger doesn't need to change airport.
+func (l *Layover) IsSameAirport() bool {
+	return l.ArrivalAirport == l.DepartureAirport
+}
+
+// IsOvernight reports whether layover spans local midnight of the connection airport.
+func (l *Layover) IsOvernight() bool {
+	return !IsSameDate(l.Arrival, l.Departure.In(l.Arrival.Location()))
+}
+
+// GetLayovers returns layovers of onward and then return flights.
+func (i *Itinerary) GetLayovers() []Layover {
+	result := make([]Layover, 0, len(i.Onward)+len(i.Return))
+	result = append(result, getLayovers(DirectionOnward, i.Onward)...)
+	return append(result, getLayovers(DirectionReturn, i.Return)...)
+}
+
+// GetStops returns number of stops of both directions.
+func (i *Itinerary) GetStops() int {
+	return i.GetOnwardStops() + i.GetReturnStops()
+}
+
+func (i *Itinerary) GetOnwardStops() int {
+	return getStops(i.Onward)
+}
+
+func (i *Itinerary) GetReturnStops() int {
+	return getStops(i.Return)
+}
+
+func getLayovers(direction string, flights []Flight) []Layover {
+	if len(flights) < 2 {
+		return nil
+	}
+
+	result := make([]Layover, 0, len(flights)-1)
+	for k := 0; k < len(flights)-1; k++ {
+		arrival, departure := flights[k].ArrivalTimeStamp.Time, flights[k+1].DepartureTimeStamp.Time
+		result = append(result, Layover{
+			Direction:        direction,
+			ArrivalAirport:   flights[k].Destination,
+			DepartureAirport: flights[k+1].Source,
+			Arrival:          arrival,
+			Departure:        departure,
+			Duration:         departure.Sub(arrival),
+		})
+	}
+	return result
+}
+
+// getStops counts connections and technical stops of flights.
+func getStops(flights []Flight) int {
+	if len(flights) == 0 {
+		return 0
+	}
+
+	result := len(flights) - 1
+	for k := range flights {
+		stops, err := strconv.Atoi(flights[k].NumberOfStops)
+		if err == nil {
+			result += stops
+		}
+	}
+	return result
+}
@@ -0,0 +1,147 @@
+package entities
+
+import (
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrInfantsWithoutAdults = errors.New("every infant should be accompanied by an adult")
+
+// Passengers is a party the itinerary is priced for.
+// Zero value means a single adult.
+type Passengers struct {
+	Adults   int
+	Children int
+	Infants  int
+}
+
+type PartyPrice struct {
+	Passengers Passengers
+	Currency   string
+	Total      decimal.Decimal
+	Breakdown  []PriceBreakdown
+}
+
+// PriceBreakdown holds single passenger charges of one passenger type
+// and their Amount for all passengers of the type.
+type PriceBreakdown struct {
+	Type         string
+	Count        int
+	BaseFare     decimal.Decimal
+	AirlineTaxes decimal.Decimal
+	TotalAmount  decimal.Decimal
+	Amount       decimal.Decimal
+}
+
+func (p Passengers) OrDefault() Passengers {
+	if p == (Passengers{}) {
+		return Passengers{Adults: 1}
+	}
+	return p
+}
+
+func (p Passengers) IsDefault() bool {
+	return p.OrDefault() == Passengers{Adults: 1}
+}
+
+func (p Passengers) Validate() error {
+	if p.Infants > p.Adults {
+		return ErrInfantsWithoutAdults
+	}
+	return nil
+}
+
+// GetCounts returns number of passengers per passenger type.
+func (p Passengers) GetCounts() map[string]int {
+	p = p.OrDefault()
+	return map[string]int{
+		TypeSingleAdult:  p.Adults,
+		TypeSingleChild:  p.Children,
+		TypeSingleInfant: p.Infants,
+	}
+}
+
+func (i *Itinerary) HasFare(rateType string) bool {
+	if i.Pricing == nil {
+		return false
+	}
+	for s := range i.Pricing.ServiceCharges {
+		if i.Pricing.ServiceCharges[s].ChargeType == ChargeTypeTotalAmount && i.Pricing.ServiceCharges[s].Type == rateType {
+			return true
+		}
+	}
+	return false
+}
+
+// CanPrice reports whether itinerary has fares for every passenger of the party.
+func (i *Itinerary) CanPrice(passengers Passengers) bool {
+	for rateType, count := range passengers.GetCounts() {
+		if count > 0 && !i.HasFare(rateType) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTotalPrice returns TotalAmount of the whole party.
+func (i *Itinerary) GetTotalPrice(passengers Passengers) decimal.Decimal {
+	result := decimal.Zero
+	for rateType, count := range passengers.GetCounts() {
+		if count > 0 {
+			result = result.Add(i.GetPrice(ChargeTypeTotalAmount, rateType).Mul(decimal.NewFromInt(int64(count))))
+		}
+	}
+	return result
+}
+
+// GetBasePrice returns TotalAmount of the whole party in the base currency,
+// it's used to compare itineraries by price.
+func (i *Itinerary) GetBasePrice(passengers Passengers) decimal.Decimal {
+	if i.BaseRate.IsZero() {
+		return i.GetTotalPrice(passengers)
+	}
+	return i.GetTotalPrice(passengers).Mul(i.BaseRate)
+}
+
+// GetBasePriced returns itineraries which prices can be compared.
+func GetBasePriced(itineraries []*Itinerary) []*Itinerary {
+	result := make([]*Itinerary, 0, len(itineraries))
+	for _, itinerary := range itineraries {
+		if !itinerary.NoBaseRate {
+			result = append(result, itinerary)
+		}
+	}
+	return result
+}
+
+func (i *Itinerary) GetPartyPrice(passengers Passengers) *PartyPrice {
+	passengers = passengers.OrDefault()
+	result := &PartyPrice{
+		Passengers: passengers,
+		Total:      decimal.Zero,
+		Breakdown:  []PriceBreakdown{},
+	}
+	if i.Pricing != nil {
+		result.Currency = i.Pricing.Currency
+	}
+
+	counts := passengers.GetCounts()
+	for _, rateType := range []string{TypeSingleAdult, TypeSingleChild, TypeSingleInfant} {
+		if counts[rateType] == 0 {
+			continue
+		}
+		breakdown := PriceBreakdown{
+			Type:         rateType,
+			Count:        counts[rateType],
+			BaseFare:     i.GetPrice(ChargeTypeBaseFare, rateType),
+			AirlineTaxes: i.GetPrice(ChargeTypeAirlineTaxes, rateType),
+			TotalAmount:  i.GetPrice(ChargeTypeTotalAmount, rateType),
+		}
+		breakdown.Amount = breakdown.TotalAmount.Mul(decimal.NewFromInt(int64(breakdown.Count)))
+		result.Total = result.Total.Add(breakdown.Amount)
+		result.Breakdown = append(result.Breakdown, breakdown)
+	}
+
+	return result
+}
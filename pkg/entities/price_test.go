@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+const pricingXML = `
+<Flights>
+	<Pricing currency="SGD">
+		<ServiceCharges type="SingleAdult" ChargeType="BaseFare">167.00</ServiceCharges>
+		<ServiceCharges type="SingleAdult" ChargeType="AirlineTaxes">215.70</ServiceCharges>
+		<ServiceCharges type="SingleAdult" ChargeType="TotalAmount">382.70</ServiceCharges>
+		<ServiceCharges type="SingleChild" ChargeType="BaseFare">129.00</ServiceCharges>
+		<ServiceCharges type="SingleChild" ChargeType="AirlineTaxes">215.70</ServiceCharges>
+		<ServiceCharges type="SingleChild" ChargeType="TotalAmount">344.70</ServiceCharges>
+	</Pricing>
+</Flights>`
+
+func TestItinerary_GetPartyPrice(t *testing.T) {
+	items := map[string]struct {
+		passengers        Passengers
+		expectedTotal     decimal.Decimal
+		expectedBreakdown int
+		expectedCanPrice  bool
+	}{
+		"it should be a single adult by default": {
+			passengers:        Passengers{},
+			expectedTotal:     decimal.NewFromFloat(382.70),
+			expectedBreakdown: 1,
+			expectedCanPrice:  true,
+		},
+		"it should be 1110.10 for 2 adults and 1 child": {
+			passengers:        Passengers{Adults: 2, Children: 1},
+			expectedTotal:     decimal.NewFromFloat(1110.10),
+			expectedBreakdown: 2,
+			expectedCanPrice:  true,
+		},
+		"it should not price infants without infant fare": {
+			passengers:        Passengers{Adults: 1, Infants: 1},
+			expectedTotal:     decimal.NewFromFloat(382.70),
+			expectedBreakdown: 2,
+			expectedCanPrice:  false,
+		},
+	}
+
+	itinerary := &Itinerary{}
+	_ = xml.Unmarshal([]byte(pricingXML), itinerary)
+
+	for message, item := range items {
+		price := itinerary.GetPartyPrice(item.passengers)
+		assert.True(t, item.expectedTotal.Equal(price.Total), message)
+		assert.True(t, item.expectedTotal.Equal(itinerary.GetTotalPrice(item.passengers)), message)
+		assert.Len(t, price.Breakdown, item.expectedBreakdown, message)
+		assert.Equal(t, "SGD", price.Currency, message)
+		assert.Equal(t, item.expectedCanPrice, itinerary.CanPrice(item.passengers), message)
+	}
+}
+
+func TestPassengers_Validate(t *testing.T) {
+	assert.NoError(t, Passengers{Adults: 1, Infants: 1}.Validate())
+	assert.Equal(t, ErrInfantsWithoutAdults, Passengers{Children: 1, Infants: 1}.Validate())
+}
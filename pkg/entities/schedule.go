@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	TimeWindowNight     = "night"
+	TimeWindowMorning   = "morning"
+	TimeWindowAfternoon = "afternoon"
+	TimeWindowEvening   = "evening"
+)
+
+var ErrInvalidTimeWindow = errors.New("time window should be night, morning, afternoon, evening or HH:MM-HH:MM")
+
+var namedTimeWindows = map[string]TimeWindow{
+	TimeWindowNight:     {From: 0, To: 6 * time.Hour},
+	TimeWindowMorning:   {From: 6 * time.Hour, To: 12 * time.Hour},
+	TimeWindowAfternoon: {From: 12 * time.Hour, To: 18 * time.Hour},
+	TimeWindowEvening:   {From: 18 * time.Hour, To: 24 * time.Hour},
+}
+
+// TimeWindow is a local time of day range [From, To), it wraps
+// around midnight when From is after To, e.g. 22:00-05:00.
+type TimeWindow struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ScheduleFilter is applied to local times of the onward direction.
+type ScheduleFilter struct {
+	DepartureDate time.Time
+	DepartureTime *TimeWindow
+	ArrivalTime   *TimeWindow
+	// ArrivalBy is a local wall clock time of the destination airport.
+	ArrivalBy time.Time
+}
+
+// ParseTimeWindow parses named window or HH:MM-HH:MM range.
+func ParseTimeWindow(value string) (*TimeWindow, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if window, ok := namedTimeWindows[value]; ok {
+		return &window, nil
+	}
+
+	bounds := strings.Split(value, "-")
+	if len(bounds) != 2 {
+		return nil, ErrInvalidTimeWindow
+	}
+
+	from, err := parseClock(bounds[0])
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseClock(bounds[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return &TimeWindow{From: from, To: to}, nil
+}
+
+func parseClock(value string) (time.Duration, error) {
+	var hours, minutes int
+	_, err := fmt.Sscanf(strings.TrimSpace(value), "%d:%d", &hours, &minutes)
+	if err != nil || hours < 0 || hours > 24 || minutes < 0 || minutes > 59 || (hours == 24 && minutes > 0) {
+		return 0, ErrInvalidTimeWindow
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
+
+// Contains reports whether local time of day of t is in the window.
+func (w *TimeWindow) Contains(t time.Time) bool {
+	hour, minute, second := t.Clock()
+	clock := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second
+	if w.From <= w.To {
+		return clock >= w.From && clock < w.To
+	}
+	return clock >= w.From || clock < w.To
+}
+
+func (f *ScheduleFilter) IsEmpty() bool {
+	return f.DepartureDate.IsZero() &&
+		f.DepartureTime == nil &&
+		f.ArrivalTime == nil &&
+		f.ArrivalBy.IsZero()
+}
+
+func (f *ScheduleFilter) Match(itinerary *Itinerary) bool {
+	if f.IsEmpty() {
+		return true
+	}
+	if len(itinerary.Onward) == 0 {
+		return false
+	}
+
+	departure := itinerary.Onward[0].DepartureTimeStamp.Time
+	arrival := itinerary.Onward[len(itinerary.Onward)-1].ArrivalTimeStamp.Time
+
+	if !f.DepartureDate.IsZero() && !IsSameDate(departure, f.DepartureDate) {
+		return false
+	}
+
+	if f.DepartureTime != nil && !f.DepartureTime.Contains(departure) {
+		return false
+	}
+
+	if f.ArrivalTime != nil && !f.ArrivalTime.Contains(arrival) {
+		return false
+	}
+
+	if !f.ArrivalBy.IsZero() {
+		year, month, day := f.ArrivalBy.Date()
+		hour, minute, second := f.ArrivalBy.Clock()
+		if arrival.After(time.Date(year, month, day, hour, minute, second, 0, arrival.Location())) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,89 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	items := map[string]struct {
+		value         string
+		expected      *TimeWindow
+		expectedError error
+	}{
+		"it should parse named window": {
+			value:    "Morning",
+			expected: &TimeWindow{From: 6 * time.Hour, To: 12 * time.Hour},
+		},
+		"it should parse explicit range": {
+			value:    "22:30-05:00",
+			expected: &TimeWindow{From: 22*time.Hour + 30*time.Minute, To: 5 * time.Hour},
+		},
+		"it should fail on unknown window": {
+			value:         "lunch",
+			expectedError: ErrInvalidTimeWindow,
+		},
+		"it should fail on malformed range": {
+			value:         "25:00-26:00",
+			expectedError: ErrInvalidTimeWindow,
+		},
+	}
+
+	for message, item := range items {
+		window, err := ParseTimeWindow(item.value)
+		assert.Equal(t, item.expectedError, err, message)
+		assert.Equal(t, item.expected, window, message)
+	}
+}
+
+func TestScheduleFilter_Match(t *testing.T) {
+	itinerary := &Itinerary{
+		Onward: []Flight{
+			newFlight("DXB", "DEL", time.Date(2018, 10, 22, 0, 5, 0, 0, time.UTC), time.Date(2018, 10, 22, 4, 45, 0, 0, time.UTC)),
+			newFlight("DEL", "BKK", time.Date(2018, 10, 22, 13, 50, 0, 0, time.UTC), time.Date(2018, 10, 22, 19, 35, 0, 0, time.UTC)),
+		},
+	}
+	night, _ := ParseTimeWindow(TimeWindowNight)
+	morning, _ := ParseTimeWindow(TimeWindowMorning)
+	lateEvening, _ := ParseTimeWindow("19:00-23:00")
+
+	items := map[string]struct {
+		filter   ScheduleFilter
+		expected bool
+	}{
+		"it should match local departure date": {
+			filter:   ScheduleFilter{DepartureDate: time.Date(2018, 10, 22, 0, 0, 0, 0, time.UTC)},
+			expected: true,
+		},
+		"it should not match another departure date": {
+			filter:   ScheduleFilter{DepartureDate: time.Date(2018, 10, 21, 0, 0, 0, 0, time.UTC)},
+			expected: false,
+		},
+		"it should match night departure": {
+			filter:   ScheduleFilter{DepartureTime: night},
+			expected: true,
+		},
+		"it should not match morning departure": {
+			filter:   ScheduleFilter{DepartureTime: morning},
+			expected: false,
+		},
+		"it should match evening arrival": {
+			filter:   ScheduleFilter{ArrivalTime: lateEvening},
+			expected: true,
+		},
+		"it should match local arrival by 20:00": {
+			filter:   ScheduleFilter{ArrivalBy: time.Date(2018, 10, 22, 20, 0, 0, 0, time.UTC)},
+			expected: true,
+		},
+		"it should not match local arrival by 19:00": {
+			filter:   ScheduleFilter{ArrivalBy: time.Date(2018, 10, 22, 19, 0, 0, 0, time.UTC)},
+			expected: false,
+		},
+	}
+
+	for message, item := range items {
+		assert.Equal(t, item.expected, item.filter.Match(itinerary), message)
+	}
+}
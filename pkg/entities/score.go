@@ -0,0 +1,20 @@
+package entities
+
+// ScoreBreakdown explains why itinerary got its rank among candidates.
+type ScoreBreakdown struct {
+	Strategy   string
+	Score      float64
+	Rank       int
+	Candidates int
+	Criteria   []CriterionScore
+}
+
+// CriterionScore is a contribution of one criterion to the score.
+// Normalized value is 1 for the best candidate and 0 for the worst one.
+type CriterionScore struct {
+	Name       string
+	Value      float64
+	Normalized float64
+	Weight     float64
+	Score      float64
+}
@@ -0,0 +1,76 @@
+package entities
+
+import "sort"
+
+const (
+	SortByPrice     = "price"
+	SortByDuration  = "duration"
+	SortByDeparture = "departure"
+	SortByArrival   = "arrival"
+	SortByStops     = "stops"
+)
+
+// ItinerarySort orders itineraries by one criterion, ties are
+// ordered by UUID so the result doesn't depend on insertion order.
+type ItinerarySort struct {
+	By         string
+	Descending bool
+	Passengers Passengers
+}
+
+func (s *ItinerarySort) Sort(itineraries []*Itinerary) {
+	sort.SliceStable(itineraries, func(i, j int) bool {
+		// prices without rate can't be compared, they come last
+		if s.By == SortByPrice && itineraries[i].NoBaseRate != itineraries[j].NoBaseRate {
+			return !itineraries[i].NoBaseRate
+		}
+		cmp := s.compare(itineraries[i], itineraries[j])
+		if cmp == 0 {
+			return itineraries[i].UUID < itineraries[j].UUID
+		}
+		if s.Descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+func (s *ItinerarySort) compare(itinerary1, itinerary2 *Itinerary) int {
+	switch s.By {
+	case SortByPrice:
+		return itinerary1.GetBasePrice(s.Passengers).Cmp(itinerary2.GetBasePrice(s.Passengers))
+	case SortByDuration:
+		return compareInt64(itinerary1.GetDuration(), itinerary2.GetDuration())
+	case SortByDeparture:
+		return compareInt64(getDepartureTime(itinerary1), getDepartureTime(itinerary2))
+	case SortByArrival:
+		return compareInt64(getArrivalTime(itinerary1), getArrivalTime(itinerary2))
+	case SortByStops:
+		return compareInt64(int64(itinerary1.GetStops()), int64(itinerary2.GetStops()))
+	}
+	return 0
+}
+
+func getDepartureTime(itinerary *Itinerary) int64 {
+	if len(itinerary.Onward) == 0 {
+		return 0
+	}
+	return itinerary.Onward[0].DepartureTimeStamp.UnixNano()
+}
+
+func getArrivalTime(itinerary *Itinerary) int64 {
+	if len(itinerary.Onward) == 0 {
+		return 0
+	}
+	return itinerary.Onward[len(itinerary.Onward)-1].ArrivalTimeStamp.UnixNano()
+}
+
+func compareInt64(value1, value2 int64) int {
+	switch {
+	case value1 < value2:
+		return -1
+	case value1 > value2:
+		return 1
+	}
+	return 0
+}
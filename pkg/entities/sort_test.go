@@ -0,0 +1,49 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItinerarySort_Sort(t *testing.T) {
+	early := &Itinerary{UUID: "c", Onward: []Flight{
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 2, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 11, 0, 0, 0, time.UTC)),
+	}}
+	late := &Itinerary{UUID: "a", Onward: []Flight{
+		newFlight("DXB", "BKK", time.Date(2018, 10, 22, 9, 0, 0, 0, time.UTC), time.Date(2018, 10, 22, 18, 0, 0, 0, time.UTC)),
+	}}
+	connecting := &Itinerary{UUID: "b", Onward: []Flight{
+		newFlight("DXB", "DEL", time.Date(2018, 10, 22, 0, 5, 0, 0, time.UTC), time.Date(2018, 10, 22, 4, 45, 0, 0, time.UTC)),
+		newFlight("DEL", "BKK", time.Date(2018, 10, 22, 13, 50, 0, 0, time.UTC), time.Date(2018, 10, 22, 19, 35, 0, 0, time.UTC)),
+	}}
+
+	items := map[string]struct {
+		sort     ItinerarySort
+		expected []*Itinerary
+	}{
+		"it should sort by departure": {
+			sort:     ItinerarySort{By: SortByDeparture},
+			expected: []*Itinerary{connecting, early, late},
+		},
+		"it should sort by arrival descending": {
+			sort:     ItinerarySort{By: SortByArrival, Descending: true},
+			expected: []*Itinerary{connecting, late, early},
+		},
+		"it should sort ties by UUID": {
+			sort:     ItinerarySort{By: SortByDuration},
+			expected: []*Itinerary{late, early, connecting},
+		},
+		"it should sort by stops": {
+			sort:     ItinerarySort{By: SortByStops, Descending: true},
+			expected: []*Itinerary{connecting, late, early},
+		},
+	}
+
+	for message, item := range items {
+		itineraries := []*Itinerary{early, late, connecting}
+		item.sort.Sort(itineraries)
+		assert.Equal(t, item.expected, itineraries, message)
+	}
+}
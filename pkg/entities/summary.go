@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ItinerarySummary is a compact view of itinerary for result lists.
+type ItinerarySummary struct {
+	UUID      ItineraryUUID
+	TripType  string
+	Price     decimal.Decimal
+	Currency  string
+	Duration  int64
+	Stops     int
+	Carriers  []string
+	Departure time.Time
+	Arrival   time.Time
+}
+
+func (i *Itinerary) GetSummary(passengers Passengers) *ItinerarySummary {
+	result := &ItinerarySummary{
+		UUID:     i.UUID,
+		TripType: i.GetTripType(),
+		Price:    i.GetTotalPrice(passengers.OrDefault()),
+		Duration: i.GetDuration(),
+		Stops:    i.GetStops(),
+		Carriers: i.GetCarriers(),
+	}
+	if i.Pricing != nil {
+		result.Currency = i.Pricing.Currency
+	}
+	if len(i.Onward) > 0 {
+		result.Departure = i.Onward[0].DepartureTimeStamp.Time
+		result.Arrival = i.Onward[len(i.Onward)-1].ArrivalTimeStamp.Time
+	}
+	return result
+}
+
+// GetCarriers returns distinct carrier codes in order of flights.
+func (i *Itinerary) GetCarriers() []string {
+	result := []string{}
+	seen := map[string]bool{}
+	for _, flights := range [][]Flight{i.Onward, i.Return} {
+		for k := range flights {
+			code := flights[k].Carrier.Code
+			if !seen[code] {
+				seen[code] = true
+				result = append(result, code)
+			}
+		}
+	}
+	return result
+}
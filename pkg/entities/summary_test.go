@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItinerary_GetSummary(t *testing.T) {
+	itinerary := &Itinerary{}
+	_ = xml.Unmarshal([]byte(`
+<Flights>
+	<OnwardPricedItinerary>
+		<Flights>
+			<Flight>
+				<Carrier id="AI">AirIndia</Carrier>
+				<Source>DXB</Source>
+				<Destination>DEL</Destination>
+				<DepartureTimeStamp>2018-10-22T0005</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-22T0445</ArrivalTimeStamp>
+				<NumberOfStops>0</NumberOfStops>
+			</Flight>
+			<Flight>
+				<Carrier id="AI">AirIndia</Carrier>
+				<Source>DEL</Source>
+				<Destination>BKK</Destination>
+				<DepartureTimeStamp>2018-10-22T1350</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-22T1935</ArrivalTimeStamp>
+				<NumberOfStops>0</NumberOfStops>
+			</Flight>
+		</Flights>
+	</OnwardPricedItinerary>
+	<ReturnPricedItinerary>
+		<Flights>
+			<Flight>
+				<Carrier id="9W">JetAirways</Carrier>
+				<Source>BKK</Source>
+				<Destination>DXB</Destination>
+				<DepartureTimeStamp>2018-10-30T0850</DepartureTimeStamp>
+				<ArrivalTimeStamp>2018-10-30T1205</ArrivalTimeStamp>
+				<NumberOfStops>1</NumberOfStops>
+			</Flight>
+		</Flights>
+	</ReturnPricedItinerary>
+	<Pricing currency="SGD">
+		<ServiceCharges type="SingleAdult" ChargeType="TotalAmount">546.80</ServiceCharges>
+	</Pricing>
+</Flights>`), itinerary)
+
+	summary := itinerary.GetSummary(Passengers{Adults: 2})
+	assert.Equal(t, TripTypeRoundTrip, summary.TripType)
+	assert.True(t, decimal.NewFromFloat(1093.60).Equal(summary.Price))
+	assert.Equal(t, "SGD", summary.Currency)
+	assert.Equal(t, 2, summary.Stops, "it should count connection and technical stop")
+	assert.Equal(t, []string{"AI", "9W"}, summary.Carriers)
+	assert.Equal(t, time.Date(2018, 10, 21, 20, 5, 0, 0, time.UTC), summary.Departure.UTC())
+}
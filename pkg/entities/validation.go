@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"aviasales/pkg/airports"
+	"fmt"
+	"strings"
+)
+
+// ParseError is a problem of a partner response file, Line is 1-based
+// and Offset is a number of bytes from the beginning of the file.
+type ParseError struct {
+	Line    int
+	Offset  int64
+	Message string
+}
+
+// ValidationError lists all problems of an itinerary.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid itinerary: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks itinerary can be searched and priced: every direction
+// has contiguous legs arriving after departure and adult TotalAmount is set.
+// Legs may change airports of the same city, see GetWarnings.
+func (i *Itinerary) Validate() error {
+	var problems []string
+	if len(i.Onward) == 0 {
+		problems = append(problems, "no onward flights")
+	}
+	problems = append(problems, validateLegs(DirectionOnward, i.Onward)...)
+	problems = append(problems, validateLegs(DirectionReturn, i.Return)...)
+
+	if !i.HasFare(TypeSingleAdult) {
+		problems = append(problems, fmt.Sprintf("no %s %s charge", TypeSingleAdult, ChargeTypeTotalAmount))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateLegs(direction string, legs []Flight) []string {
+	var problems []string
+	for k := range legs {
+		leg := &legs[k]
+		name := fmt.Sprintf("%s leg %d %s-%s", direction, k+1, leg.Source, leg.Destination)
+		if leg.Source == "" || leg.Destination == "" {
+			problems = append(problems, name+": no airport")
+		}
+		if !leg.ArrivalTimeStamp.After(leg.DepartureTimeStamp.Time) {
+			problems = append(problems, name+": arrival is not after departure")
+		}
+		if k > 0 && legs[k-1].Destination != leg.Source && !isSameCity(legs[k-1].Destination, leg.Source) {
+			problems = append(problems, fmt.Sprintf("%s: previous leg arrives to %s", name, legs[k-1].Destination))
+		}
+	}
+	return problems
+}
+
+// GetWarnings lists airport changes within a city between legs, such
+// itineraries are valid, layover filters tell them apart.
+func (i *Itinerary) GetWarnings() []string {
+	var warnings []string
+	for _, direction := range []struct {
+		name string
+		legs []Flight
+	}{
+		{DirectionOnward, i.Onward},
+		{DirectionReturn, i.Return},
+	} {
+		for k := 1; k < len(direction.legs); k++ {
+			previous, leg := &direction.legs[k-1], &direction.legs[k]
+			if previous.Destination != leg.Source && isSameCity(previous.Destination, leg.Source) {
+				warnings = append(warnings, fmt.Sprintf("%s leg %d %s-%s: airport is changed from %s",
+					direction.name, k+1, leg.Source, leg.Destination, previous.Destination))
+			}
+		}
+	}
+	return warnings
+}
+
+func isSameCity(airport1, airport2 string) bool {
+	city1, ok1 := airports.Get(airport1)
+	city2, ok2 := airports.Get(airport2)
+	return ok1 && ok2 && city1.CityCode == city2.CityCode
+}
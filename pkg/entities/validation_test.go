@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestItinerary_Validate(t *testing.T) {
+	pricing := &Price{
+		Currency: "SGD",
+		ServiceCharges: []Charge{
+			{ChargeType: ChargeTypeTotalAmount, Type: TypeSingleAdult, Cost: decimal.NewFromFloat(382.70)},
+		},
+	}
+	toDelhi := newFlight("DXB", "DEL", time.Date(2018, 10, 27, 0, 5, 0, 0, time.UTC), time.Date(2018, 10, 27, 4, 45, 0, 0, time.UTC))
+	toBangkok := newFlight("DEL", "BKK", time.Date(2018, 10, 27, 13, 25, 0, 0, time.UTC), time.Date(2018, 10, 27, 19, 20, 0, 0, time.UTC))
+	fromGuangzhou := newFlight("CAN", "BKK", time.Date(2018, 10, 27, 13, 25, 0, 0, time.UTC), time.Date(2018, 10, 27, 15, 20, 0, 0, time.UTC))
+	fromMaktoum := newFlight("BKK", "DWC", time.Date(2018, 10, 30, 8, 0, 0, 0, time.UTC), time.Date(2018, 10, 30, 11, 0, 0, 0, time.UTC))
+	toDubai := newFlight("DXB", "DEL", time.Date(2018, 10, 30, 15, 0, 0, 0, time.UTC), time.Date(2018, 10, 30, 19, 0, 0, 0, time.UTC))
+	backwards := newFlight("DEL", "BKK", time.Date(2018, 10, 27, 19, 20, 0, 0, time.UTC), time.Date(2018, 10, 27, 13, 25, 0, 0, time.UTC))
+
+	items := map[string]struct {
+		itinerary *Itinerary
+		problems  []string
+	}{
+		"it should accept contiguous priced legs": {
+			itinerary: &Itinerary{Onward: []Flight{toDelhi, toBangkok}, Pricing: pricing},
+		},
+		"it should reject itinerary without flights": {
+			itinerary: &Itinerary{Pricing: pricing},
+			problems:  []string{"no onward flights"},
+		},
+		"it should reject not contiguous legs": {
+			itinerary: &Itinerary{Onward: []Flight{toDelhi, fromGuangzhou}, Pricing: pricing},
+			problems:  []string{"O leg 2 CAN-BKK: previous leg arrives to DEL"},
+		},
+		"it should accept airport change within the city": {
+			itinerary: &Itinerary{Onward: []Flight{toDelhi, toBangkok}, Return: []Flight{fromMaktoum, toDubai}, Pricing: pricing},
+		},
+		"it should reject arrival before departure": {
+			itinerary: &Itinerary{Onward: []Flight{toDelhi}, Return: []Flight{backwards}, Pricing: pricing},
+			problems:  []string{"R leg 1 DEL-BKK: arrival is not after departure"},
+		},
+		"it should reject itinerary without adult TotalAmount": {
+			itinerary: &Itinerary{Onward: []Flight{toDelhi}},
+			problems:  []string{"no SingleAdult TotalAmount charge"},
+		},
+	}
+
+	for message, item := range items {
+		err := item.itinerary.Validate()
+		if item.problems == nil {
+			assert.NoError(t, err, message)
+			continue
+		}
+		if assert.IsType(t, &ValidationError{}, err, message) {
+			assert.Equal(t, item.problems, err.(*ValidationError).Problems, message)
+		}
+	}
+}
+
+func TestFlightDate_UnmarshalXML(t *testing.T) {
+	flight := &Flight{}
+	err := xml.Unmarshal([]byte(`<Flight><DepartureTimeStamp>2018-10-27 00:05</DepartureTimeStamp></Flight>`), flight)
+	assert.Error(t, err, "it should fail on malformed timestamp")
+	assert.True(t, flight.DepartureTimeStamp.IsZero())
+}
+
+func TestItinerary_GetWarnings(t *testing.T) {
+	toMaktoum := newFlight("BKK", "DWC", time.Date(2018, 10, 30, 8, 0, 0, 0, time.UTC), time.Date(2018, 10, 30, 11, 0, 0, 0, time.UTC))
+	fromDubai := newFlight("DXB", "DEL", time.Date(2018, 10, 30, 15, 0, 0, 0, time.UTC), time.Date(2018, 10, 30, 19, 0, 0, 0, time.UTC))
+	itinerary := &Itinerary{Onward: []Flight{toMaktoum, fromDubai}}
+
+	assert.Equal(t, []string{"O leg 2 DXB-DEL: airport is changed from DWC"}, itinerary.GetWarnings(), "it should warn of airport change within the city")
+	assert.False(t, itinerary.GetLayovers()[0].IsSameAirport(), "it should keep the change for layover filters")
+}